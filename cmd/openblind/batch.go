@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/jacoelho/openblind/fetch/client"
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+	"github.com/jacoelho/openblind/writer"
+)
+
+// readURLs reads one url per line from path or stdin, skipping blank lines and # comments
+func readURLs(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != stdinInput {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var result []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result = append(result, line)
+	}
+
+	return result, scanner.Err()
+}
+
+// splitURLs splits a comma separated list of urls
+func splitURLs(s string) []string {
+	var result []string
+	for _, u := range strings.Split(s, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			result = append(result, u)
+		}
+	}
+	return result
+}
+
+// runBatch crawls every company writing records tagged with their company,
+// failed companies are logged and the remaining ones still written
+func runBatch(cfg config, w writer.CompanyWriter) error {
+	urls := cfg.urls
+	if cfg.batch != "" {
+		fromFile, err := readURLs(cfg.batch)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, fromFile...)
+	}
+
+	if len(urls) == 0 {
+		return errors.New("no company urls to crawl")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	handler := client.BatchHandler{
+		Review: func(company client.Company, r reviews.Review) error {
+			return w.WriteCompanyReview(writer.CompanyReview{Company: company.Name, CompanyID: company.ID, Review: r})
+		},
+		Interview: func(company client.Company, i interviews.Interview) error {
+			return w.WriteCompanyInterview(writer.CompanyInterview{Company: company.Name, CompanyID: company.ID, Interview: i})
+		},
+	}
+
+	err := newClient(cfg).CrawlBatch(ctx, urls, cfg.workers, crawlOptions(cfg), handler)
+
+	var batchErr *client.BatchError
+	if errors.As(err, &batchErr) {
+		for _, companyErr := range batchErr.Errors {
+			log.Printf("error: %v", companyErr)
+		}
+		return fmt.Errorf("%d of %d companies failed", len(batchErr.Errors), batchErr.Total)
+	}
+
+	return err
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/jacoelho/openblind/fetch/client"
+)
+
+// cookieFlags registers the cookie jar flags
+func cookieFlags(fs *flag.FlagSet, cfg *config) {
+	fs.StringVar(&cfg.cookies, "cookies", "", "cookies.txt file loaded before and saved after fetching")
+	fs.StringVar(&cfg.importCookies, "import-cookies", "", "cookies exported from a browser, cookies.txt or json, added to the jar")
+}
+
+// loadJar returns the jar of the cookie flags, nil when neither is set
+func loadJar(cfg config) (*client.Jar, error) {
+	if cfg.cookies == "" && cfg.importCookies == "" {
+		return nil, nil
+	}
+
+	jar := client.NewJar()
+	if cfg.cookies != "" {
+		loaded, err := client.LoadJar(cfg.cookies)
+		if err != nil {
+			return nil, err
+		}
+		jar = loaded
+	}
+
+	if cfg.importCookies != "" {
+		f, err := os.Open(cfg.importCookies)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		if err := jar.Import(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return jar, nil
+}
+
+// saveJar writes the jar back to the cookies file
+func saveJar(cfg config) error {
+	if cfg.jar == nil || cfg.cookies == "" {
+		return nil
+	}
+	return cfg.jar.SaveFile(cfg.cookies)
+}
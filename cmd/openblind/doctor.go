@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jacoelho/openblind"
+	"github.com/jacoelho/openblind/fetch/client"
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+	"github.com/jacoelho/openblind/section"
+	"golang.org/x/net/html"
+)
+
+const doctorCommand = "doctor"
+
+// exitCodeDegraded tells a page was read but the selectors no longer extract everything
+const exitCodeDegraded = 2
+
+// sectionCheck parses a section leniently calling fn with every record
+type sectionCheck struct {
+	section   section.Section
+	selectors *openblind.Selectors
+	parse     func(root *html.Node, diagnostic func(error), fn func(record interface{})) error
+}
+
+func sectionChecks(sel selectors) []sectionCheck {
+	return []sectionCheck{
+		{
+			section:   section.Interviews,
+			selectors: sel.interviews,
+			parse: func(root *html.Node, diagnostic func(error), fn func(interface{})) error {
+				opts := interviews.Options{Lenient: true, Diagnostic: diagnostic, Selectors: sel.interviews}
+				return interviews.ParseNode(root, opts, func(v interviews.Interview) error {
+					fn(v)
+					return nil
+				})
+			},
+		},
+		{
+			section:   section.Reviews,
+			selectors: sel.reviews,
+			parse: func(root *html.Node, diagnostic func(error), fn func(interface{})) error {
+				opts := reviews.Options{Lenient: true, Diagnostic: diagnostic, Selectors: sel.reviews}
+				return reviews.ParseNode(root, opts, func(v reviews.Review) error {
+					fn(v)
+					return nil
+				})
+			},
+		},
+	}
+}
+
+// emptyFields counts, per field, the records where the field has its zero value,
+// fields are named after their json key and kept in declaration order
+type emptyFields struct {
+	names  []string
+	counts map[string]int
+}
+
+func (e *emptyFields) add(record interface{}) {
+	if e.counts == nil {
+		e.counts = make(map[string]int)
+	}
+
+	v := reflect.ValueOf(record)
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = t.Field(i).Name
+		}
+
+		if _, found := e.counts[name]; !found {
+			e.names = append(e.names, name)
+			e.counts[name] = 0
+		}
+
+		if v.Field(i).IsZero() {
+			e.counts[name]++
+		}
+	}
+}
+
+// doctorSection prints the matcher counts and empty fields of a section,
+// returning the reasons the section is degraded
+func doctorSection(w io.Writer, root *html.Node, check sectionCheck, allowEmpty map[string]bool) []string {
+	inspection := check.selectors.Inspect(root)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\n", check.section)
+	fmt.Fprintf(tw, "  %s\t%s\t%d nodes\n", inspection.List.Name, inspection.List.Selector, inspection.List.Nodes)
+	fmt.Fprintf(tw, "  %s\t%s\t%d nodes\n", inspection.Record.Name, inspection.Record.Selector, inspection.Record.Nodes)
+	for _, field := range inspection.Fields {
+		fmt.Fprintf(tw, "  %s\t%s\t%d nodes\tin %d/%d records\n", field.Name, field.Selector, field.Nodes, field.Records, inspection.Record.Nodes)
+	}
+	tw.Flush()
+
+	if inspection.List.Nodes == 0 {
+		return nil
+	}
+
+	var (
+		degraded []string
+		records  int
+		failed   int
+		empty    emptyFields
+	)
+
+	err := check.parse(root, func(err error) {
+		failed++
+		fmt.Fprintf(w, "  skipped: %v\n", err)
+	}, func(record interface{}) {
+		records++
+		empty.add(record)
+	})
+	if err != nil {
+		return append(degraded, fmt.Sprintf("%s: %v", check.section, err))
+	}
+
+	if records == 0 {
+		degraded = append(degraded, fmt.Sprintf("%s: no records parsed", check.section))
+	}
+
+	if failed > 0 {
+		degraded = append(degraded, fmt.Sprintf("%s: %d records failed to parse", check.section, failed))
+	}
+
+	var summary []string
+	for _, name := range empty.names {
+		count := empty.counts[name]
+		if count == 0 {
+			continue
+		}
+
+		summary = append(summary, fmt.Sprintf("%s %d/%d", name, count, records))
+
+		if count == records && !allowEmpty[name] {
+			degraded = append(degraded, fmt.Sprintf("%s: %s empty in every record", check.section, name))
+		}
+	}
+
+	if len(summary) > 0 {
+		fmt.Fprintf(w, "  empty fields: %s\n", strings.Join(summary, ", "))
+	}
+
+	return degraded
+}
+
+// doctorDocument reports every section of the document, the document must belong to one of them
+func doctorDocument(w io.Writer, data []byte, sel selectors, allowEmpty map[string]bool) ([]string, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var degraded []string
+	if _, err := section.DetectSelectors(root, sel.interviews, sel.reviews); err != nil {
+		degraded = append(degraded, err.Error())
+	}
+
+	for _, check := range sectionChecks(sel) {
+		degraded = append(degraded, doctorSection(w, root, check, allowEmpty)...)
+	}
+
+	return degraded, nil
+}
+
+// runDoctor reports how the selectors match a fetched or saved page,
+// exiting with exitCodeDegraded when the page is no longer fully extracted
+func runDoctor(args []string) int {
+	var (
+		cfg        config
+		profile    string
+		allowEmpty string
+	)
+
+	fs := flag.NewFlagSet(doctorCommand, flag.ExitOnError)
+	fs.StringVar(&cfg.targetURL, "url", "", "url to check")
+	fs.StringVar(&cfg.input, "input", "", "saved page, directory of saved pages or - for stdin to check instead of fetching")
+	fs.DurationVar(&cfg.timeout, "timeout", client.DefaultTimeout, "timeout duration per request")
+	fs.StringVar(&cfg.userAgent, "user-agent", client.DefaultUserAgent, "user agent to use")
+	retryFlags(fs, &cfg.retry)
+	rateFlags(fs, &cfg.rateLimit)
+	cookieFlags(fs, &cfg)
+	fs.StringVar(&profile, "profile", "", "selector profile file, defaults to the embedded profile")
+	fs.StringVar(&allowEmpty, "allow-empty", "advice", "comma separated fields allowed to be empty in every record")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s (-url <page> | -input <saved page>)\n", os.Args[0], doctorCommand)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if (cfg.targetURL == "") == (cfg.input == "") {
+		fs.Usage()
+		return exitCodeError
+	}
+
+	sel, err := loadSelectors(profile)
+	if err != nil {
+		log.Println(err)
+		return exitCodeError
+	}
+
+	cfg.jar, err = loadJar(cfg)
+	if err != nil {
+		log.Println(err)
+		return exitCodeError
+	}
+
+	allowed := make(map[string]bool)
+	for _, name := range strings.Split(allowEmpty, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed[name] = true
+		}
+	}
+
+	var docs []document
+	if cfg.input != "" {
+		docs, err = readDocuments(cfg.input)
+	} else {
+		var data []byte
+		data, err = fetchPage(cfg)
+		docs = []document{{name: cfg.targetURL, data: data}}
+	}
+	if err == nil {
+		err = saveJar(cfg)
+	}
+	if err != nil {
+		log.Println(err)
+		return exitCodeError
+	}
+
+	code := exitCodeOK
+	for _, doc := range docs {
+		fmt.Printf("# %s\n", doc.name)
+
+		degraded, err := doctorDocument(os.Stdout, doc.data, sel, allowed)
+		if err != nil {
+			log.Println(err)
+			return exitCodeError
+		}
+
+		for _, reason := range degraded {
+			fmt.Printf("DEGRADED %s\n", reason)
+			code = exitCodeDegraded
+		}
+	}
+
+	return code
+}
+
+// fetchPage returns the body of the first page of the target url
+func fetchPage(cfg config) ([]byte, error) {
+	body, err := newClient(cfg).Fetch(context.Background(), cfg.targetURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return ioutil.ReadAll(body)
+}
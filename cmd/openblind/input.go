@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+	"github.com/jacoelho/openblind/section"
+	"github.com/jacoelho/openblind/state"
+	"github.com/jacoelho/openblind/writer"
+	"golang.org/x/net/html"
+)
+
+const stdinInput = "-"
+
+type document struct {
+	name string
+	data []byte
+}
+
+// readDocuments reads a single file, every saved page in a directory or stdin
+func readDocuments(path string) ([]document, error) {
+	if path == stdinInput {
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		return []document{{name: "stdin", data: data}}, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if info.IsDir() {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if entry.Mode().IsRegular() && (ext == ".htm" || ext == ".html") {
+				paths = append(paths, filepath.Join(path, entry.Name()))
+			}
+		}
+	} else {
+		paths = []string{path}
+	}
+
+	result := make([]document, 0, len(paths))
+	for _, p := range paths {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, document{name: p, data: data})
+	}
+
+	return result, nil
+}
+
+// parseDocument writes the document records to w, detecting the section when needed,
+// every document must belong to the same section as the first one
+func parseDocument(doc document, cfg config, first *section.Section, w writer.Writer) error {
+	s := cfg.section
+
+	if s == section.Auto {
+		root, err := html.Parse(bytes.NewReader(doc.data))
+		if err != nil {
+			return err
+		}
+
+		s, err = section.DetectSelectors(root, cfg.selectors.interviews, cfg.selectors.reviews)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *first != "" && *first != s {
+		return fmt.Errorf("found %s, expected %s: mixed sections in input", s, *first)
+	}
+	*first = s
+
+	if s == section.Interviews {
+		opts := interviews.Options{Lenient: !cfg.strict, Diagnostic: warn, Selectors: cfg.selectors.interviews}
+		return state.ParseInterviewsFunc(bytes.NewReader(doc.data), opts, w.WriteInterview)
+	}
+
+	opts := reviews.Options{Lenient: !cfg.strict, Diagnostic: warn, Selectors: cfg.selectors.reviews}
+	return state.ParseReviewsFunc(bytes.NewReader(doc.data), opts, w.WriteReview)
+}
+
+func runInput(cfg config, w writer.Writer) error {
+	docs, err := readDocuments(cfg.input)
+	if err != nil {
+		return err
+	}
+
+	if len(docs) == 0 {
+		return fmt.Errorf("%s: no saved pages found", cfg.input)
+	}
+
+	var first section.Section
+	for _, doc := range docs {
+		if err := parseDocument(doc, cfg, &first, w); err != nil {
+			return fmt.Errorf("%s: %w", doc.name, err)
+		}
+	}
+
+	return nil
+}
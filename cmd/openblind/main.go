@@ -2,17 +2,20 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
-	"net/http"
 	"net/url"
 	"os"
 	"time"
 
-	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/crawler"
+	"github.com/jacoelho/openblind/fetch/client"
+	"github.com/jacoelho/openblind/query"
 	"github.com/jacoelho/openblind/reviews"
+	"github.com/jacoelho/openblind/section"
+	"github.com/jacoelho/openblind/writer"
 )
 
 const (
@@ -22,14 +25,36 @@ const (
 
 type config struct {
 	targetURL string
+	input     string
+	batch     string
+	urls      []string
+	workers   int
 	timeout   time.Duration
-	section   string
+	section   section.Section
 	userAgent string
+	pages     int
+	since     time.Time
+	query     query.Options
+	format    string
+	separator string
+	strict    bool
+	selectors selectors
+	retry     client.RetryPolicy
+	rateLimit client.RateLimit
+
+	cookies       string
+	importCookies string
+	jar           *client.Jar
 }
 
+const sinceFormat = "2006-01-02"
+
 const (
-	sectionInterviews = "interviews"
-	sectionReviews    = "reviews"
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatTSV  = "tsv"
+
+	formatNDJSON = "ndjson"
 )
 
 var version string = "development"
@@ -38,12 +63,46 @@ func main() {
 	var (
 		c           config
 		showVersion bool
+		since       string
+		sectionName string
+		sort        string
+		status      string
+		profile     string
+		urls        string
 	)
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case validateCommand:
+			os.Exit(runValidate(os.Args[2:]))
+		case doctorCommand:
+			os.Exit(runDoctor(os.Args[2:]))
+		}
+	}
+
 	flag.StringVar(&c.targetURL, "url", "", "url to parse")
-	flag.DurationVar(&c.timeout, "timeout", 5*time.Second, "timeout duration")
-	flag.StringVar(&c.section, "section", "interviews", "type of section, one of: interviews, reviews")
-	flag.StringVar(&c.userAgent, "user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/89.0.4389.114 Safari/537.36", "user agent to use")
+	flag.DurationVar(&c.timeout, "timeout", client.DefaultTimeout, "timeout duration per request")
+	flag.StringVar(&c.input, "input", "", "parse a saved page, a directory of saved pages or - for stdin instead of fetching")
+	flag.StringVar(&c.batch, "batch", "", "file with a company url per line or - for stdin to crawl in batch")
+	flag.StringVar(&urls, "urls", "", "comma separated company urls to crawl in batch")
+	flag.IntVar(&c.workers, "workers", client.DefaultWorkers, "number of companies crawled at the same time in batch")
+	flag.StringVar(&sectionName, "section", "auto", "type of section, one of: auto, interviews, reviews")
+	flag.StringVar(&c.userAgent, "user-agent", client.DefaultUserAgent, "user agent to use")
+	retryFlags(flag.CommandLine, &c.retry)
+	rateFlags(flag.CommandLine, &c.rateLimit)
+	cookieFlags(flag.CommandLine, &c)
+	flag.IntVar(&c.pages, "pages", 1, "maximum number of pages to fetch, 0 for all")
+	flag.StringVar(&since, "since", "", "drop records older than date, stopping at the first one when sorted by recent descending, format: 2006-01-02")
+	flag.StringVar(&sort, "sort", "recent", "sort order, one of: recent, rating, helpful")
+	flag.BoolVar(&c.query.Ascending, "ascending", false, "sort in ascending order")
+	flag.StringVar(&c.query.JobTitle, "job-title", "", "filter by job title")
+	flag.StringVar(&c.query.Location, "location", "", "filter by location")
+	flag.StringVar(&status, "employment-status", "", "filter by employment status, one of: current, former")
+	flag.IntVar(&c.query.Rating, "rating", 0, "filter by overall rating, from 1 to 5")
+	flag.StringVar(&c.format, "format", formatJSON, "output format, one of: json, ndjson, csv, tsv")
+	flag.StringVar(&c.separator, "separator", writer.DefaultSeparator, "separator of list fields in csv and tsv output")
+	flag.BoolVar(&c.strict, "strict", false, "fail on the first malformed record instead of skipping it with a warning")
+	flag.StringVar(&profile, "profile", "", "selector profile file, defaults to the embedded profile, only used for pages without application state")
 	flag.BoolVar(&showVersion, "version", false, "show version")
 	flag.Parse()
 
@@ -52,62 +111,198 @@ func main() {
 		os.Exit(exitCodeOK)
 	}
 
-	if c.targetURL == "" {
+	c.urls = splitURLs(urls)
+
+	sources := 0
+	for _, set := range []bool{c.targetURL != "", c.input != "", c.batch != "" || len(c.urls) > 0} {
+		if set {
+			sources++
+		}
+	}
+
+	if sources != 1 || c.workers < 1 {
 		flag.Usage()
 		os.Exit(exitCodeError)
 	}
 
-	if c.section != sectionInterviews && c.section != sectionReviews {
+	c.section = section.Section(sectionName)
+	switch c.section {
+	case section.Auto, section.Interviews, section.Reviews:
+	default:
 		flag.Usage()
 		os.Exit(exitCodeError)
 	}
 
-	if err := run(c); err != nil {
-		log.Println(err)
+	switch c.format {
+	case formatJSON, formatNDJSON, formatCSV, formatTSV:
+	default:
+		flag.Usage()
 		os.Exit(exitCodeError)
 	}
 
-}
+	if c.pages < 0 {
+		flag.Usage()
+		os.Exit(exitCodeError)
+	}
 
-func run(cfg config) error {
-	timeout, cancel := context.WithTimeout(context.Background(), cfg.timeout)
-	defer cancel()
+	c.query.Sort = query.Sort(sort)
+	c.query.EmploymentStatus = reviews.EmploymentStatus(status)
 
-	u, err := url.Parse(cfg.targetURL)
-	if err != nil {
-		return err
+	if since != "" {
+		parsed, err := time.Parse(sinceFormat, since)
+		if err != nil {
+			log.Println(err)
+			os.Exit(exitCodeError)
+		}
+		c.since = parsed
 	}
 
-	u.Query().Set("sort.sortType", "RD")
-	u.Query().Set("sort.ascending", "false")
+	sel, err := loadSelectors(profile)
+	if err != nil {
+		log.Println(err)
+		os.Exit(exitCodeError)
+	}
+	c.selectors = sel
 
-	req, err := http.NewRequestWithContext(timeout, http.MethodGet, u.String(), nil)
+	jar, err := loadJar(c)
 	if err != nil {
-		return err
+		log.Println(err)
+		os.Exit(exitCodeError)
 	}
+	c.jar = jar
 
-	req.Header.Set("User-Agent", cfg.userAgent)
+	err = run(c)
+
+	// cookies are saved even after a failure, they may satisfy the next run
+	if saveErr := saveJar(c); err == nil {
+		err = saveErr
+	}
 
-	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return err
+		log.Println(err)
+		os.Exit(exitCodeError)
 	}
 
-	defer resp.Body.Close()
+}
+
+func newClient(cfg config) *client.Client {
+	opts := []client.Option{
+		client.WithTimeout(cfg.timeout),
+		client.WithUserAgent(cfg.userAgent),
+		client.WithRetryPolicy(cfg.retry),
+		client.WithRateLimit(cfg.rateLimit),
+	}
 
-	var res interface{}
-	if cfg.section == sectionInterviews {
-		res, err = interviews.Parse(resp.Body)
-	} else {
-		res, err = reviews.Parse(resp.Body)
+	if cfg.jar != nil {
+		opts = append(opts, client.WithCookieJar(cfg.jar))
 	}
+
+	return client.New(opts...)
+}
+
+// retryFlags registers the retry policy flags, defaulting to client.DefaultRetryPolicy
+func retryFlags(fs *flag.FlagSet, policy *client.RetryPolicy) {
+	*policy = client.DefaultRetryPolicy
+	fs.IntVar(&policy.MaxRetries, "retries", policy.MaxRetries, "retries per request on throttling and server errors, 0 to disable")
+	fs.IntVar(&policy.Budget, "retry-budget", policy.Budget, "retries allowed across all requests, grown by -retry-ratio, both 0 for no limit")
+	fs.Float64Var(&policy.BudgetRatio, "retry-ratio", policy.BudgetRatio, "retries added to -retry-budget per request made, 0.1 is one every 10 requests")
+}
+
+// rateFlags registers the rate limit flags, by default one request per second per host
+func rateFlags(fs *flag.FlagSet, limit *client.RateLimit) {
+	fs.Float64Var(&limit.Rate, "rate", 1, "maximum requests per second per host, 0 for no limit")
+	fs.IntVar(&limit.Burst, "burst", 1, "requests per host allowed back to back before -rate applies")
+	fs.DurationVar(&limit.Delay, "delay", 0, "maximum random delay added before each request")
+}
+
+// warn logs records skipped in lenient mode
+func warn(err error) {
+	log.Printf("warning: %v", err)
+}
+
+// outputSection returns the section of the records written, empty when only known once detected
+func outputSection(cfg config) section.Section {
+	if cfg.section != section.Auto {
+		return cfg.section
+	}
+
+	// saved pages and batches may hold either section
+	if cfg.input != "" || cfg.batch != "" || len(cfg.urls) > 0 {
+		return ""
+	}
+
+	u, err := url.Parse(cfg.targetURL)
 	if err != nil {
-		return err
+		return ""
+	}
+
+	s, _ := section.FromURL(u)
+	return s
+}
+
+func newWriter(cfg config, w io.Writer) writer.CompanyWriter {
+	opts := writer.Options{
+		Separator: cfg.separator,
+		Section:   outputSection(cfg),
+		Company:   cfg.batch != "" || len(cfg.urls) > 0,
+	}
+
+	switch cfg.format {
+	case formatCSV:
+		return writer.NewCSV(w, opts)
+	case formatTSV:
+		return writer.NewTSV(w, opts)
+	case formatNDJSON:
+		return writer.NewNDJSON(w)
+	default:
+		return writer.NewJSON(w)
+	}
+}
+
+func run(cfg config) error {
+	w := newWriter(cfg, os.Stdout)
+
+	var err error
+	switch {
+	case cfg.input != "":
+		err = runInput(cfg, w)
+	case cfg.batch != "" || len(cfg.urls) > 0:
+		err = runBatch(cfg, w)
+	default:
+		err = runURL(cfg, w)
+	}
+
+	// flush records written before any error
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
 	}
 
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "\t")
-	enc.Encode(&res)
+	return err
+}
+
+// crawlOptions returns the options of every crawl
+func crawlOptions(cfg config) client.Options {
+	return client.Options{
+		Options: crawler.Options{
+			MaxPages: cfg.pages,
+			Since:    cfg.since,
+
+			Lenient:    !cfg.strict,
+			Diagnostic: warn,
+
+			ReviewSelectors:    cfg.selectors.reviews,
+			InterviewSelectors: cfg.selectors.interviews,
+		},
+		Section: cfg.section,
+		Query:   cfg.query,
+	}
+}
+
+func runURL(cfg config, w writer.Writer) error {
+	handler := client.Handler{
+		Review:    w.WriteReview,
+		Interview: w.WriteInterview,
+	}
 
-	return nil
+	return newClient(cfg).Crawl(context.Background(), cfg.targetURL, crawlOptions(cfg), handler)
 }
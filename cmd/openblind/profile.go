@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/jacoelho/openblind"
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+)
+
+// selectors are the compiled selectors of every section
+type selectors struct {
+	reviews    *openblind.Selectors
+	interviews *openblind.Selectors
+}
+
+// loadSelectors compiles the profile at path, the embedded profile when path is empty
+func loadSelectors(path string) (selectors, error) {
+	profile := openblind.DefaultProfile()
+	if path != "" {
+		p, err := openblind.LoadProfile(path)
+		if err != nil {
+			return selectors{}, err
+		}
+		profile = p
+	}
+
+	reviewSelectors, err := reviews.NewSelectors(profile)
+	if err != nil {
+		return selectors{}, err
+	}
+
+	interviewSelectors, err := interviews.NewSelectors(profile)
+	if err != nil {
+		return selectors{}, err
+	}
+
+	return selectors{reviews: reviewSelectors, interviews: interviewSelectors}, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+	"github.com/jacoelho/openblind/section"
+	"golang.org/x/net/html"
+)
+
+const validateCommand = "validate"
+
+var errNoRecords = errors.New("no records found")
+
+// validateDocument parses the document strictly with the profile selectors,
+// returning the detected section and the number of records
+func validateDocument(doc document, sel selectors) (section.Section, int, error) {
+	root, err := html.Parse(bytes.NewReader(doc.data))
+	if err != nil {
+		return "", 0, err
+	}
+
+	s, err := section.DetectSelectors(root, sel.interviews, sel.reviews)
+	if err != nil {
+		return "", 0, err
+	}
+
+	count := 0
+	if s == section.Interviews {
+		err = interviews.ParseNode(root, interviews.Options{Selectors: sel.interviews}, func(interviews.Interview) error {
+			count++
+			return nil
+		})
+	} else {
+		err = reviews.ParseNode(root, reviews.Options{Selectors: sel.reviews}, func(reviews.Review) error {
+			count++
+			return nil
+		})
+	}
+
+	if err == nil && count == 0 {
+		err = errNoRecords
+	}
+
+	return s, count, err
+}
+
+// runValidate checks a selector profile against saved pages, every page must be
+// detected as a single section and have at least one record parsing without errors
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet(validateCommand, flag.ExitOnError)
+	profile := fs.String("profile", "", "selector profile file, defaults to the embedded profile")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [-profile file] <saved page or directory>...\n", os.Args[0], validateCommand)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitCodeError
+	}
+
+	sel, err := loadSelectors(*profile)
+	if err != nil {
+		log.Println(err)
+		return exitCodeError
+	}
+
+	code := exitCodeOK
+	for _, path := range fs.Args() {
+		docs, err := readDocuments(path)
+		if err == nil && len(docs) == 0 {
+			err = errors.New("no saved pages found")
+		}
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", path, err)
+			code = exitCodeError
+			continue
+		}
+
+		for _, doc := range docs {
+			s, count, err := validateDocument(doc, sel)
+			if err != nil {
+				fmt.Printf("FAIL %s: %v\n", doc.name, err)
+				code = exitCodeError
+				continue
+			}
+
+			fmt.Printf("ok   %s: %d %s\n", doc.name, count, s)
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,198 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/jacoelho/openblind"
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+	"github.com/jacoelho/openblind/state"
+)
+
+var (
+	pageRe = regexp.MustCompile(`(_P\d+)?\.htm$`)
+
+	ErrUnsupportedURL = errors.New("unsupported url")
+)
+
+// Fetcher returns the body of the page at url, the caller closes it
+type Fetcher func(ctx context.Context, url string) (io.ReadCloser, error)
+
+type Options struct {
+	// MaxPages is the maximum number of pages to fetch, zero means no limit
+	MaxPages int
+
+	// Since drops records older than it and, unless Unsorted, stops the crawl once one is found
+	Since time.Time
+
+	// Unsorted tells pages are not sorted by most recent first,
+	// Since then filters records without stopping the crawl
+	Unsorted bool
+
+	// Lenient skips malformed records instead of failing the crawl
+	Lenient bool
+
+	// Diagnostic receives the skipped records
+	Diagnostic openblind.Diagnostic
+
+	// ReviewSelectors and InterviewSelectors locate the records,
+	// the default profile is used when nil. Pages are parsed from their
+	// application state when present, the selectors only apply otherwise
+	ReviewSelectors    *openblind.Selectors
+	InterviewSelectors *openblind.Selectors
+}
+
+// PageURL returns the url of the given page, pages start at 1
+// example: Tesla-Reviews-E43129.htm, Tesla-Reviews-E43129_P2.htm
+func PageURL(rawURL string, page int) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	if !pageRe.MatchString(u.Path) {
+		return "", fmt.Errorf("%s: %w", rawURL, ErrUnsupportedURL)
+	}
+
+	suffix := ".htm"
+	if page > 1 {
+		suffix = fmt.Sprintf("_P%d.htm", page)
+	}
+
+	u.Path = pageRe.ReplaceAllLiteralString(u.Path, suffix)
+	u.RawPath = ""
+
+	return u.String(), nil
+}
+
+// crawl fetches pages until parse reports there is nothing more to read
+func crawl(ctx context.Context, fetch Fetcher, rawURL string, opts Options, parse func(pageURL string, r io.Reader) (bool, error)) error {
+	for page := 1; opts.MaxPages == 0 || page <= opts.MaxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		pageURL, err := PageURL(rawURL, page)
+		if err != nil {
+			return err
+		}
+
+		body, err := fetch(ctx, pageURL)
+		if err != nil {
+			return err
+		}
+
+		more, err := parse(pageURL, body)
+		body.Close()
+		if err != nil {
+			return fmt.Errorf("page %d: %w", page, err)
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return nil
+}
+
+// ReviewsFunc crawls the reviews pages starting at rawURL calling fn for each review,
+// records are read from the embedded application state when present and de-duplicated by id
+func ReviewsFunc(ctx context.Context, fetch Fetcher, rawURL string, opts Options, fn func(reviews.Review) error) error {
+	seen := make(map[string]struct{})
+
+	return crawl(ctx, fetch, rawURL, opts, func(_ string, r io.Reader) (bool, error) {
+		fresh, expired := 0, false
+
+		parseOpts := reviews.Options{
+			Lenient:    opts.Lenient,
+			Diagnostic: opts.Diagnostic,
+			Selectors:  opts.ReviewSelectors,
+		}
+
+		err := state.ParseReviewsFunc(r, parseOpts, func(review reviews.Review) error {
+			if _, found := seen[review.ID]; found {
+				return nil
+			}
+			seen[review.ID] = struct{}{}
+			fresh++
+
+			if !opts.Since.IsZero() && review.Date.Before(opts.Since) {
+				expired = !opts.Unsorted
+				return nil
+			}
+
+			return fn(review)
+		})
+
+		return fresh > 0 && !expired, err
+	})
+}
+
+// Reviews crawls the reviews pages starting at rawURL, records are de-duplicated by id
+func Reviews(ctx context.Context, fetch Fetcher, rawURL string, opts Options) ([]reviews.Review, error) {
+	var result []reviews.Review
+
+	err := ReviewsFunc(ctx, fetch, rawURL, opts, func(review reviews.Review) error {
+		result = append(result, review)
+		return nil
+	})
+
+	return result, err
+}
+
+// InterviewsFunc crawls the interviews pages starting at rawURL calling fn for each interview,
+// records are read from the embedded application state when present and de-duplicated by id
+func InterviewsFunc(ctx context.Context, fetch Fetcher, rawURL string, opts Options, fn func(interviews.Interview) error) error {
+	seen := make(map[string]struct{})
+
+	return crawl(ctx, fetch, rawURL, opts, func(pageURL string, r io.Reader) (bool, error) {
+		fresh, expired := 0, false
+
+		parseOpts := interviews.Options{
+			Lenient:    opts.Lenient,
+			Diagnostic: opts.Diagnostic,
+			Selectors:  opts.InterviewSelectors,
+		}
+
+		// question links are resolved against the page, keeping its locale domain
+		if base, err := url.Parse(pageURL); err == nil {
+			parseOpts.BaseURL = base
+		}
+
+		err := state.ParseInterviewsFunc(r, parseOpts, func(interview interviews.Interview) error {
+			if _, found := seen[interview.ID]; found {
+				return nil
+			}
+			seen[interview.ID] = struct{}{}
+			fresh++
+
+			if !opts.Since.IsZero() && interview.Date.Before(opts.Since) {
+				expired = !opts.Unsorted
+				return nil
+			}
+
+			return fn(interview)
+		})
+
+		return fresh > 0 && !expired, err
+	})
+}
+
+// Interviews crawls the interviews pages starting at rawURL, records are de-duplicated by id
+func Interviews(ctx context.Context, fetch Fetcher, rawURL string, opts Options) ([]interviews.Interview, error) {
+	var result []interviews.Interview
+
+	err := InterviewsFunc(ctx, fetch, rawURL, opts, func(interview interviews.Interview) error {
+		result = append(result, interview)
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,163 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jacoelho/openblind/internal/fixture"
+)
+
+func TestPageURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		page    int
+		want    string
+		wantErr error
+	}{
+		{
+			name: "first page",
+			url:  "https://www.glassdoor.co.uk/Reviews/Tesla-Reviews-E43129.htm",
+			page: 1,
+			want: "https://www.glassdoor.co.uk/Reviews/Tesla-Reviews-E43129.htm",
+		},
+		{
+			name: "second page",
+			url:  "https://www.glassdoor.co.uk/Reviews/Tesla-Reviews-E43129.htm?sort.sortType=RD",
+			page: 2,
+			want: "https://www.glassdoor.co.uk/Reviews/Tesla-Reviews-E43129_P2.htm?sort.sortType=RD",
+		},
+		{
+			name: "replaces existing page",
+			url:  "https://www.glassdoor.co.uk/Interview/Tesla-Interview-Questions-E43129_P5.htm",
+			page: 3,
+			want: "https://www.glassdoor.co.uk/Interview/Tesla-Interview-Questions-E43129_P3.htm",
+		},
+		{
+			name: "back to first page",
+			url:  "https://www.glassdoor.co.uk/Interview/Tesla-Interview-Questions-E43129_P5.htm",
+			page: 1,
+			want: "https://www.glassdoor.co.uk/Interview/Tesla-Interview-Questions-E43129.htm",
+		},
+		{
+			name:    "unsupported",
+			url:     "https://www.glassdoor.co.uk/Reviews/",
+			page:    2,
+			wantErr: ErrUnsupportedURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PageURL(tt.url, tt.page)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PageURL() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("PageURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func reviewIDs(t *testing.T, site *fixture.Site, opts Options) []string {
+	t.Helper()
+
+	result, err := Reviews(context.Background(), site.Fetch, "https://example.com/Reviews/Company-Reviews-E1.htm", opts)
+	if err != nil {
+		t.Fatalf("Reviews() error = %v", err)
+	}
+
+	ids := make([]string, len(result))
+	for i, r := range result {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestReviews(t *testing.T) {
+	const (
+		page1 = "https://example.com/Reviews/Company-Reviews-E1.htm"
+		page2 = "https://example.com/Reviews/Company-Reviews-E1_P2.htm"
+		page3 = "https://example.com/Reviews/Company-Reviews-E1_P3.htm"
+	)
+
+	tests := []struct {
+		name        string
+		pages       map[string]string
+		opts        Options
+		want        []string
+		wantFetched []string
+	}{
+		{
+			name: "stops when page is empty",
+			pages: map[string]string{
+				page1: fixture.Page(fixture.Review("1", "Sun Apr 04 2021"), fixture.Review("2", "Sat Apr 03 2021")),
+				page2: fixture.Page(),
+			},
+			want:        []string{"1", "2"},
+			wantFetched: []string{page1, page2},
+		},
+		{
+			name: "stops when page repeats",
+			pages: map[string]string{
+				page1: fixture.Page(fixture.Review("1", "Sun Apr 04 2021"), fixture.Review("2", "Sat Apr 03 2021")),
+				page2: fixture.Page(fixture.Review("2", "Sat Apr 03 2021"), fixture.Review("3", "Fri Apr 02 2021")),
+				page3: fixture.Page(fixture.Review("2", "Sat Apr 03 2021"), fixture.Review("3", "Fri Apr 02 2021")),
+			},
+			want:        []string{"1", "2", "3"},
+			wantFetched: []string{page1, page2, page3},
+		},
+		{
+			name: "stops at max pages",
+			pages: map[string]string{
+				page1: fixture.Page(fixture.Review("1", "Sun Apr 04 2021")),
+				page2: fixture.Page(fixture.Review("2", "Sat Apr 03 2021")),
+			},
+			opts:        Options{MaxPages: 1},
+			want:        []string{"1"},
+			wantFetched: []string{page1},
+		},
+		{
+			name: "stops at since",
+			pages: map[string]string{
+				page1: fixture.Page(fixture.Review("1", "Sun Apr 04 2021"), fixture.Review("2", "Sat Apr 03 2021")),
+				page2: fixture.Page(fixture.Review("3", "Fri Apr 02 2021"), fixture.Review("4", "Thu Apr 01 2021")),
+				page3: fixture.Page(fixture.Review("5", "Wed Mar 31 2021")),
+			},
+			opts:        Options{Since: time.Date(2021, 4, 2, 0, 0, 0, 0, time.UTC)},
+			want:        []string{"1", "2", "3"},
+			wantFetched: []string{page1, page2},
+		},
+		{
+			name: "filters since when unsorted",
+			pages: map[string]string{
+				page1: fixture.Page(fixture.Review("1", "Thu Apr 01 2021"), fixture.Review("2", "Sun Apr 04 2021")),
+				page2: fixture.Page(fixture.Review("3", "Wed Mar 31 2021")),
+				page3: fixture.Page(fixture.Review("4", "Sat Apr 03 2021")),
+			},
+			opts:        Options{MaxPages: 3, Since: time.Date(2021, 4, 2, 0, 0, 0, 0, time.UTC), Unsorted: true},
+			want:        []string{"2", "4"},
+			wantFetched: []string{page1, page2, page3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := &fixture.Site{Pages: tt.pages}
+
+			got := reviewIDs(t, site, tt.opts)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Reviews() mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantFetched, site.Fetched()); diff != "" {
+				t.Errorf("Reviews() fetched mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
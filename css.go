@@ -0,0 +1,554 @@
+package openblind
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var ErrInvalidSelector = errors.New("invalid selector")
+
+// Compile parses a CSS selector into a Matcher.
+// Supported: type, universal, #id, .class, attribute ([a], =, ^=, $=, *=, ~=, |=),
+// descendant, child (>), adjacent (+) and general sibling (~) combinators,
+// :nth-child, :first-child, :last-child, :not and selector lists.
+func Compile(selector string) (Matcher, error) {
+	p := &cssParser{s: selector}
+
+	m, err := p.parseGroup(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+
+	return NewMatcher(selector, m), nil
+}
+
+// MustCompile is like Compile but panics if the selector cannot be parsed
+func MustCompile(selector string) Matcher {
+	m, err := Compile(selector)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// cssPart is a compound selector and the combinator joining it to the previous part
+type cssPart struct {
+	combinator byte
+	match      func(*html.Node) bool
+}
+
+type cssParser struct {
+	s   string
+	pos int
+}
+
+func (p *cssParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%q at position %d: %s: %w", p.s, p.pos, fmt.Sprintf(format, args...), ErrInvalidSelector)
+}
+
+func (p *cssParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *cssParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *cssParser) skipSpace() bool {
+	start := p.pos
+	for !p.eof() && isSpace(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// parseGroup parses a comma separated list of selectors until stop or the end of input
+func (p *cssParser) parseGroup(stop byte) (func(*html.Node) bool, error) {
+	var selectors []func(*html.Node) bool
+
+	for {
+		p.skipSpace()
+
+		m, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, m)
+
+		p.skipSpace()
+		if p.eof() || p.peek() == stop {
+			break
+		}
+
+		if p.peek() != ',' {
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+		p.pos++
+	}
+
+	if len(selectors) == 1 {
+		return selectors[0], nil
+	}
+
+	return func(n *html.Node) bool {
+		for _, m := range selectors {
+			if m(n) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// parseComplex parses compound selectors joined by combinators
+func (p *cssParser) parseComplex() (func(*html.Node) bool, error) {
+	first, err := p.parseCompound()
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []cssPart{{match: first}}
+
+	for {
+		hadSpace := p.skipSpace()
+
+		c := p.peek()
+		if p.eof() || c == ',' || c == ')' {
+			break
+		}
+
+		combinator := byte(' ')
+		switch c {
+		case '>', '+', '~':
+			combinator = c
+			p.pos++
+			p.skipSpace()
+		default:
+			if !hadSpace {
+				return nil, p.errorf("unexpected %q", c)
+			}
+		}
+
+		compound, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, cssPart{combinator: combinator, match: compound})
+	}
+
+	return func(n *html.Node) bool {
+		return matchParts(parts, len(parts)-1, n)
+	}, nil
+}
+
+func matchParts(parts []cssPart, i int, n *html.Node) bool {
+	if !parts[i].match(n) {
+		return false
+	}
+
+	if i == 0 {
+		return true
+	}
+
+	switch parts[i].combinator {
+	case '>':
+		return n.Parent != nil && matchParts(parts, i-1, n.Parent)
+	case '+':
+		prev := previousElement(n)
+		return prev != nil && matchParts(parts, i-1, prev)
+	case '~':
+		for prev := previousElement(n); prev != nil; prev = previousElement(prev) {
+			if matchParts(parts, i-1, prev) {
+				return true
+			}
+		}
+		return false
+	default:
+		for parent := n.Parent; parent != nil; parent = parent.Parent {
+			if matchParts(parts, i-1, parent) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// parseCompound parses a sequence of simple selectors applying to the same element
+func (p *cssParser) parseCompound() (func(*html.Node) bool, error) {
+	var tests []func(*html.Node) bool
+
+	start := p.pos
+
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+	case isNameStart(c):
+		tag := strings.ToLower(p.parseName())
+		tests = append(tests, func(n *html.Node) bool {
+			return n.Data == tag
+		})
+	}
+
+loop:
+	for {
+		switch p.peek() {
+		case '#':
+			p.pos++
+			id := p.parseName()
+			if id == "" {
+				return nil, p.errorf("expected id")
+			}
+			tests = append(tests, func(n *html.Node) bool {
+				v, found := WithAttr(n, "id")
+				return found && v == id
+			})
+		case '.':
+			p.pos++
+			class := p.parseName()
+			if class == "" {
+				return nil, p.errorf("expected class")
+			}
+			tests = append(tests, func(n *html.Node) bool {
+				v, found := WithAttr(n, "class")
+				return found && containsField(v, class)
+			})
+		case '[':
+			test, err := p.parseAttribute()
+			if err != nil {
+				return nil, err
+			}
+			tests = append(tests, test)
+		case ':':
+			test, err := p.parsePseudo()
+			if err != nil {
+				return nil, err
+			}
+			tests = append(tests, test)
+		default:
+			break loop
+		}
+	}
+
+	if p.pos == start {
+		if p.eof() {
+			return nil, p.errorf("expected selector")
+		}
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+
+	return func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return false
+		}
+
+		for _, test := range tests {
+			if !test(n) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// parseAttribute parses [name], [name=value] and the other attribute operators
+func (p *cssParser) parseAttribute() (func(*html.Node) bool, error) {
+	p.pos++ // [
+	p.skipSpace()
+
+	name := strings.ToLower(p.parseName())
+	if name == "" {
+		return nil, p.errorf("expected attribute name")
+	}
+
+	p.skipSpace()
+	if p.peek() == ']' {
+		p.pos++
+		return func(n *html.Node) bool {
+			_, found := WithAttr(n, name)
+			return found
+		}, nil
+	}
+
+	var op string
+	switch c := p.peek(); c {
+	case '=':
+		op = "="
+		p.pos++
+	case '^', '$', '*', '~', '|':
+		if p.pos+1 >= len(p.s) || p.s[p.pos+1] != '=' {
+			return nil, p.errorf("expected %q", string(c)+"=")
+		}
+		op = string(c) + "="
+		p.pos += 2
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+
+	p.skipSpace()
+
+	var (
+		value string
+		err   error
+	)
+	if c := p.peek(); c == '"' || c == '\'' {
+		value, err = p.parseString()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		value = p.parseName()
+		if value == "" {
+			return nil, p.errorf("expected attribute value")
+		}
+	}
+
+	p.skipSpace()
+	if p.peek() != ']' {
+		return nil, p.errorf("expected ]")
+	}
+	p.pos++
+
+	var compare func(string) bool
+	switch op {
+	case "=":
+		compare = func(v string) bool { return v == value }
+	case "^=":
+		compare = func(v string) bool { return value != "" && strings.HasPrefix(v, value) }
+	case "$=":
+		compare = func(v string) bool { return value != "" && strings.HasSuffix(v, value) }
+	case "*=":
+		compare = func(v string) bool { return value != "" && strings.Contains(v, value) }
+	case "~=":
+		compare = func(v string) bool { return containsField(v, value) }
+	case "|=":
+		compare = func(v string) bool { return v == value || strings.HasPrefix(v, value+"-") }
+	}
+
+	return func(n *html.Node) bool {
+		v, found := WithAttr(n, name)
+		return found && compare(v)
+	}, nil
+}
+
+// parsePseudo parses the supported pseudo-classes
+func (p *cssParser) parsePseudo() (func(*html.Node) bool, error) {
+	p.pos++ // :
+
+	name := strings.ToLower(p.parseName())
+	switch name {
+	case "first-child":
+		return func(n *html.Node) bool {
+			return previousElement(n) == nil
+		}, nil
+	case "last-child":
+		return func(n *html.Node) bool {
+			return nextElement(n) == nil
+		}, nil
+	case "nth-child":
+		if p.peek() != '(' {
+			return nil, p.errorf("expected (")
+		}
+		p.pos++
+
+		end := strings.IndexByte(p.s[p.pos:], ')')
+		if end < 0 {
+			return nil, p.errorf("expected )")
+		}
+
+		a, b, ok := parseNth(p.s[p.pos : p.pos+end])
+		if !ok {
+			return nil, p.errorf("invalid nth-child argument %q", p.s[p.pos:p.pos+end])
+		}
+		p.pos += end + 1
+
+		return func(n *html.Node) bool {
+			return matchNth(a, b, elementIndex(n))
+		}, nil
+	case "not":
+		if p.peek() != '(' {
+			return nil, p.errorf("expected (")
+		}
+		p.pos++
+
+		m, err := p.parseGroup(')')
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+
+		return func(n *html.Node) bool {
+			return !m(n)
+		}, nil
+	case "":
+		return nil, p.errorf("expected pseudo-class")
+	default:
+		return nil, p.errorf("unsupported pseudo-class %q", name)
+	}
+}
+
+// parseName reads an identifier, backslash escapes the next character
+func (p *cssParser) parseName() string {
+	var sb strings.Builder
+
+	for !p.eof() {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s):
+			sb.WriteByte(p.s[p.pos+1])
+			p.pos += 2
+		case isNameChar(c):
+			sb.WriteByte(c)
+			p.pos++
+		default:
+			return sb.String()
+		}
+	}
+
+	return sb.String()
+}
+
+// parseString reads a single or double quoted string
+func (p *cssParser) parseString() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.s[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\' && p.pos+1 < len(p.s):
+			sb.WriteByte(p.s[p.pos+1])
+			p.pos += 2
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+// parseNth parses the an+b notation, including odd and even
+func parseNth(s string) (int, int, bool) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), ""))
+
+	switch s {
+	case "odd":
+		return 2, 1, true
+	case "even":
+		return 2, 0, true
+	}
+
+	idx := strings.IndexByte(s, 'n')
+	if idx < 0 {
+		b, err := strconv.Atoi(s)
+		return 0, b, err == nil
+	}
+
+	var a int
+	switch aPart := s[:idx]; aPart {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		v, err := strconv.Atoi(aPart)
+		if err != nil {
+			return 0, 0, false
+		}
+		a = v
+	}
+
+	bPart := s[idx+1:]
+	if bPart == "" {
+		return a, 0, true
+	}
+
+	if bPart[0] != '+' && bPart[0] != '-' {
+		return 0, 0, false
+	}
+
+	b, err := strconv.Atoi(bPart)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return a, b, true
+}
+
+// matchNth reports whether index is a*n+b for some n >= 0
+func matchNth(a, b, index int) bool {
+	if a == 0 {
+		return index == b
+	}
+
+	diff := index - b
+	return diff/a >= 0 && diff%a == 0
+}
+
+// elementIndex returns the 1-based position of n among its element siblings
+func elementIndex(n *html.Node) int {
+	index := 1
+	for prev := previousElement(n); prev != nil; prev = previousElement(prev) {
+		index++
+	}
+	return index
+}
+
+func previousElement(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+func nextElement(n *html.Node) *html.Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+func containsField(s, field string) bool {
+	for _, v := range strings.Fields(s) {
+		if v == field {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c == '-' || c == '\\' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
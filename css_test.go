@@ -0,0 +1,183 @@
+package openblind
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+const cssFixture = `<html><body>
+<div id="feed" class="list main" data-test="ReviewsFeed">
+	<ol id="reviews">
+		<li id="r1" class="review cf" data-test="Review1Container" lang="en-GB">
+			<h2 id="t1" class="h2 summary strong">Great</h2>
+			<span id="p1" data-test="pros">pros</span>
+			<span id="c1" data-test="cons">cons</span>
+		</li>
+		<li id="r2" class="review" data-test="Review2Container" lang="en">
+			<h2 id="t2" class="h2 summary">Good</h2>
+			<span id="p2" data-test="pros">pros</span>
+		</li>
+		<li id="r3" class="review featured" data-test="Review3Container" lang="pt">
+			<h2 id="t3">Okay</h2>
+		</li>
+		<li id="r4" class="review" data-test="Review4Container">
+			<p id="x4"><span id="s4">nested</span></p>
+		</li>
+	</ol>
+	<p id="footer" class="v2__EIReviewDetailsV2__bodyColor small">footer</p>
+</div>
+</body></html>`
+
+func selectIDs(t *testing.T, root *html.Node, m Matcher) []string {
+	t.Helper()
+
+	var ids []string
+	for _, n := range FindAll(root, m) {
+		id, _ := WithAttr(n, "id")
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestCompile(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(cssFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{selector: "ol", want: []string{"reviews"}},
+		{selector: "OL", want: []string{"reviews"}},
+		{selector: "#r2", want: []string{"r2"}},
+		{selector: ".review", want: []string{"r1", "r2", "r3", "r4"}},
+		{selector: ".review.featured", want: []string{"r3"}},
+		{selector: "li.cf", want: []string{"r1"}},
+		{selector: "*#t1", want: []string{"t1"}},
+		{selector: "[lang]", want: []string{"r1", "r2", "r3"}},
+		{selector: `[data-test="pros"]`, want: []string{"p1", "p2"}},
+		{selector: "[data-test='cons']", want: []string{"c1"}},
+		{selector: "[data-test=pros]", want: []string{"p1", "p2"}},
+		{selector: "[data-test^=Review][data-test$=Container]", want: []string{"r1", "r2", "r3", "r4"}},
+		{selector: "[data-test^=Review]", want: []string{"feed", "r1", "r2", "r3", "r4"}},
+		{selector: "[data-test$=Feed]", want: []string{"feed"}},
+		{selector: "[data-test*=view3]", want: []string{"r3"}},
+		{selector: "[class~=summary]", want: []string{"t1", "t2"}},
+		{selector: "[class~=summ]", want: nil},
+		{selector: "[lang|=en]", want: []string{"r1", "r2"}},
+		{selector: "[class^=v2__EIReviewDetailsV2__]", want: []string{"footer"}},
+		{selector: "[ data-test = pros ]", want: []string{"p1", "p2"}},
+		{selector: "ol span", want: []string{"p1", "c1", "p2", "s4"}},
+		{selector: "li > span", want: []string{"p1", "c1", "p2"}},
+		{selector: "li>span", want: []string{"p1", "c1", "p2"}},
+		{selector: "#feed > ol > li > h2", want: []string{"t1", "t2", "t3"}},
+		{selector: "#feed > span", want: nil},
+		{selector: "div li p span", want: []string{"s4"}},
+		{selector: "h2 + span", want: []string{"p1", "p2"}},
+		{selector: "h2 ~ span", want: []string{"p1", "c1", "p2"}},
+		{selector: "ol ~ p", want: []string{"footer"}},
+		{selector: "li:nth-child(2)", want: []string{"r2"}},
+		{selector: "li:nth-child(odd)", want: []string{"r1", "r3"}},
+		{selector: "li:nth-child(even)", want: []string{"r2", "r4"}},
+		{selector: "li:nth-child(2n+1)", want: []string{"r1", "r3"}},
+		{selector: "li:nth-child(-n+2)", want: []string{"r1", "r2"}},
+		{selector: "li:nth-child(n+3)", want: []string{"r3", "r4"}},
+		{selector: "li:nth-child( 3n - 1 )", want: []string{"r2"}},
+		{selector: "li:first-child", want: []string{"r1"}},
+		{selector: "li:last-child", want: []string{"r4"}},
+		{selector: "li:not(.featured)", want: []string{"r1", "r2", "r4"}},
+		{selector: "li:not([lang], .featured)", want: []string{"r4"}},
+		{selector: "span:not(li > span)", want: []string{"s4"}},
+		{selector: "h2, #footer", want: []string{"t1", "t2", "t3", "footer"}},
+		{selector: `.review\:featured`, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			m, err := Compile(tt.selector)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			got := selectIDs(t, root, m)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Compile(%q) mismatch (-want +got):\n%s", tt.selector, diff)
+			}
+		})
+	}
+}
+
+func TestCompileFind(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(cssFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	review, found := Find(root, MustCompile("li:nth-child(2)"))
+	if !found {
+		t.Fatal("Find() review not found")
+	}
+
+	title, found := Find(review, MustCompile("h2.summary"))
+	if !found {
+		t.Fatal("Find() title not found")
+	}
+
+	if diff := cmp.Diff([]string{"Good"}, ExtractText(title)); diff != "" {
+		t.Errorf("ExtractText() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		" ",
+		"div >",
+		"div,",
+		",div",
+		"#",
+		".",
+		"[",
+		"[data-test",
+		"[data-test=]",
+		"[data-test=pros",
+		"[data-test^pros]",
+		"[data-test!=pros]",
+		`[data-test="pros]`,
+		"li:",
+		"li:hover",
+		"li:nth-child",
+		"li:nth-child(2",
+		"li:nth-child(x)",
+		"li:nth-child(2n1)",
+		"li:not(.a",
+		"li:not()",
+		"div)",
+		"div > > span",
+	}
+
+	for _, selector := range tests {
+		t.Run(selector, func(t *testing.T) {
+			_, err := Compile(selector)
+			if !errors.Is(err, ErrInvalidSelector) {
+				t.Errorf("Compile(%q) error = %v, want %v", selector, err, ErrInvalidSelector)
+			}
+		})
+	}
+}
+
+func TestMustCompilePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile() did not panic")
+		}
+	}()
+
+	MustCompile("li:hover")
+}
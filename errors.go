@@ -0,0 +1,125 @@
+package openblind
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ParseError describes which record and field failed to parse and where in the document,
+// it unwraps to the underlying sentinel error
+type ParseError struct {
+	Section  string
+	RecordID string
+	Field    string
+	Matcher  string
+	Path     string
+	Err      error
+}
+
+// Diagnostic receives, in lenient mode, the error of every record skipped
+// because a required field failed to parse, optional fields are left empty silently
+type Diagnostic func(error)
+
+// NewParseError returns a ParseError for field, the path goes from root down to node
+func NewParseError(section string, root, node *html.Node, field string, m Matcher, err error) *ParseError {
+	e := &ParseError{
+		Section: section,
+		Field:   field,
+		Path:    NodePath(root, node),
+		Err:     err,
+	}
+
+	if m != nil {
+		e.Matcher = m.String()
+	}
+
+	return e
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString(e.Section)
+
+	if e.RecordID != "" {
+		sb.WriteString(" record ")
+		sb.WriteString(e.RecordID)
+	}
+
+	if e.Field != "" {
+		sb.WriteString(": field ")
+		sb.WriteString(e.Field)
+	}
+
+	if e.Matcher != "" {
+		sb.WriteString(" (")
+		sb.WriteString(e.Matcher)
+		sb.WriteString(")")
+	}
+
+	if e.Path != "" {
+		sb.WriteString(" at ")
+		sb.WriteString(e.Path)
+	}
+
+	sb.WriteString(": ")
+	sb.WriteString(e.Err.Error())
+
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// WithRecordID sets the record id of the ParseError in err, if any
+func WithRecordID(err error, id string) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		parseErr.RecordID = id
+	}
+
+	return err
+}
+
+// NodePath describes the elements from root down to node,
+// e.g. li#empReview_45005756 > div.gdReview > span.rating
+func NodePath(root, node *html.Node) string {
+	var elements []string
+
+	for n := node; n != nil; n = n.Parent {
+		if n.Type == html.ElementNode {
+			elements = append(elements, describeNode(n))
+		}
+
+		if n == root {
+			break
+		}
+	}
+
+	for i, j := 0, len(elements)-1; i < j; i, j = i+1, j-1 {
+		elements[i], elements[j] = elements[j], elements[i]
+	}
+
+	return strings.Join(elements, " > ")
+}
+
+func describeNode(n *html.Node) string {
+	var sb strings.Builder
+
+	sb.WriteString(n.Data)
+
+	if id, found := WithAttr(n, "id"); found && id != "" {
+		sb.WriteString("#")
+		sb.WriteString(id)
+	}
+
+	for _, class := range classes(n) {
+		sb.WriteString(".")
+		sb.WriteString(class)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+package openblind
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseError(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<li id="empReview_1" class="empReview cf"><div class="gdReview"><span class="rating">x</span></div></li>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, _ := Find(root, WithID("empReview_1"))
+	rating, _ := Find(record, HasClass("rating"))
+
+	errRating := errors.New("failed to parse rating")
+
+	err = WithRecordID(NewParseError("reviews", record, rating, "rating", HasClass("rating"), errRating), "1")
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("errors.As() = false, want ParseError")
+	}
+
+	if want := "li#empReview_1.empReview.cf > div.gdReview > span.rating"; parseErr.Path != want {
+		t.Errorf("Path = %q, want %q", parseErr.Path, want)
+	}
+
+	if !errors.Is(err, errRating) {
+		t.Errorf("errors.Is() = false, want true")
+	}
+
+	want := "reviews record 1: field rating (.rating) at li#empReview_1.empReview.cf > div.gdReview > span.rating: failed to parse rating"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestNodePath(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div id="feed"><ol><li class="a b"><span>x</span></li></ol></div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span, _ := Find(root, WithTag("span"))
+
+	tests := []struct {
+		name string
+		root *html.Node
+		want string
+	}{
+		{name: "from document", root: root, want: "html > body > div#feed > ol > li.a.b > span"},
+		{name: "from element", root: span.Parent.Parent, want: "ol > li.a.b > span"},
+		{name: "same node", root: span, want: "span"},
+		{name: "no root", root: nil, want: "html > body > div#feed > ol > li.a.b > span"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NodePath(tt.root, span); got != tt.want {
+				t.Errorf("NodePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+)
+
+// DefaultWorkers is the number of companies crawled at the same time
+const DefaultWorkers = 4
+
+// BatchHandler receives the records of a batch with the company they belong to,
+// handlers are never called concurrently
+type BatchHandler struct {
+	Review    func(Company, reviews.Review) error
+	Interview func(Company, interviews.Interview) error
+}
+
+// CompanyError is the failure of one url of a batch
+type CompanyError struct {
+	URL     string
+	Company Company
+	Err     error
+}
+
+func (e *CompanyError) Error() string {
+	return fmt.Sprintf("%s: %v", e.URL, e.Err)
+}
+
+func (e *CompanyError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError lists, in input order, the urls of a batch that failed
+type BatchError struct {
+	Total  int
+	Errors []*CompanyError
+}
+
+func (e *BatchError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d of %d companies failed: %s", len(e.Errors), e.Total, strings.Join(messages, "; "))
+}
+
+// handlerError marks the errors returned by a batch handler,
+// telling them apart from crawl errors of the same company
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+func (e *handlerError) Unwrap() error {
+	return e.err
+}
+
+// CrawlBatch crawls every url with up to workers at a time, a failing url does not stop the others.
+// Urls not crawled once ctx is done fail with the context error, failures are returned as *BatchError.
+// An error returned by the handler, such as a failing writer, cancels the batch and is returned as is
+func (c *Client) CrawlBatch(ctx context.Context, urls []string, workers int, opts Options, h BatchHandler) error {
+	if workers < 1 {
+		workers = DefaultWorkers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu         sync.Mutex
+		errs       = make([]*CompanyError, len(urls))
+		handlerErr error
+	)
+
+	// call serializes handlers so callers can share a writer,
+	// the first handler error stops every crawl
+	call := func(fn func() error) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if handlerErr != nil {
+			return &handlerError{err: handlerErr}
+		}
+
+		if err := fn(); err != nil {
+			handlerErr = err
+			cancel()
+			return &handlerError{err: err}
+		}
+
+		return nil
+	}
+
+	handler := func(company Company) Handler {
+		var result Handler
+
+		if h.Review != nil {
+			result.Review = func(r reviews.Review) error {
+				return call(func() error { return h.Review(company, r) })
+			}
+		}
+
+		if h.Interview != nil {
+			result.Interview = func(i interviews.Interview) error {
+				return call(func() error { return h.Interview(company, i) })
+			}
+		}
+
+		return result
+	}
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for idx := range jobs {
+				rawURL := urls[idx]
+
+				company, err := CompanyFromURL(rawURL)
+				if err == nil {
+					err = c.Crawl(ctx, rawURL, opts, handler(company))
+				}
+
+				var hErr *handlerError
+				if err != nil && !errors.As(err, &hErr) {
+					errs[idx] = &CompanyError{URL: rawURL, Company: company, Err: err}
+				}
+			}
+		}()
+	}
+
+	next := 0
+feed:
+	for ; next < len(urls); next++ {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- next:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	for idx := next; idx < len(urls); idx++ {
+		company, _ := CompanyFromURL(urls[idx])
+		errs[idx] = &CompanyError{URL: urls[idx], Company: company, Err: ctx.Err()}
+	}
+
+	result := &BatchError{Total: len(urls)}
+	for _, err := range errs {
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
+	if len(result.Errors) == 0 {
+		return nil
+	}
+
+	return result
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jacoelho/openblind/crawler"
+	"github.com/jacoelho/openblind/internal/fixture"
+	"github.com/jacoelho/openblind/reviews"
+)
+
+// collect returns a batch handler appending company and review ids
+func collect(got *[]string) BatchHandler {
+	return BatchHandler{
+		Review: func(company Company, r reviews.Review) error {
+			*got = append(*got, company.Name+"/"+r.ID)
+			return nil
+		},
+	}
+}
+
+func TestCrawlBatch(t *testing.T) {
+	const (
+		tesla   = "https://www.glassdoor.com/Reviews/Tesla-Reviews-E43129.htm"
+		goldman = "https://www.glassdoor.com/Reviews/Goldman-Sachs-Reviews-E2800.htm"
+		missing = "https://www.glassdoor.com/Reviews/Missing-Reviews-E1.htm"
+		unknown = "https://www.glassdoor.com/Overview/Working-at-Tesla-EI_IE43129.11,16.htm"
+	)
+
+	site := &fixture.Site{Pages: map[string]string{
+		tesla:   fixture.Page(fixture.Review("1", fixture.Date), fixture.Review("2", fixture.Date)),
+		goldman: fixture.Page(fixture.Review("3", fixture.Date)),
+	}}
+
+	var got []string
+	err := New(WithTransport(site)).CrawlBatch(context.Background(), []string{tesla, missing, goldman, unknown}, 2, Options{Options: crawler.Options{MaxPages: 1}}, collect(&got))
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("CrawlBatch() error = %v, want *BatchError", err)
+	}
+
+	if batchErr.Total != 4 {
+		t.Errorf("CrawlBatch() total = %d, want 4", batchErr.Total)
+	}
+
+	var failed []string
+	for _, companyErr := range batchErr.Errors {
+		failed = append(failed, companyErr.URL)
+	}
+
+	if diff := cmp.Diff([]string{missing, unknown}, failed); diff != "" {
+		t.Errorf("CrawlBatch() failed mismatch (-want +got):\n%s", diff)
+	}
+
+	var statusErr *StatusError
+	if !errors.As(batchErr.Errors[0], &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("CrawlBatch() error = %v, want status %d", batchErr.Errors[0], http.StatusNotFound)
+	}
+
+	if !errors.Is(batchErr.Errors[1], ErrUnknownCompany) {
+		t.Errorf("CrawlBatch() error = %v, want %v", batchErr.Errors[1], ErrUnknownCompany)
+	}
+
+	// workers finish in any order
+	sort.Strings(got)
+
+	if diff := cmp.Diff([]string{"Goldman-Sachs/3", "Tesla/1", "Tesla/2"}, got); diff != "" {
+		t.Errorf("CrawlBatch() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCrawlBatchWorkers(t *testing.T) {
+	const workers = 2
+
+	var inFlight, maxInFlight int64
+
+	c := New(WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+
+		for {
+			max := atomic.LoadInt64(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt64(&maxInFlight, max, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		site := &fixture.Site{Pages: map[string]string{req.URL.String(): fixture.Page(fixture.Review("1", fixture.Date))}}
+		return site.RoundTrip(req)
+	})))
+
+	urls := make([]string, 6)
+	for i := range urls {
+		urls[i] = c.ReviewsURL(Company{Name: "Company", ID: strconv.Itoa(i + 1)})
+	}
+
+	var got []string
+	if err := c.CrawlBatch(context.Background(), urls, workers, Options{Options: crawler.Options{MaxPages: 1}}, collect(&got)); err != nil {
+		t.Fatalf("CrawlBatch() error = %v", err)
+	}
+
+	if len(got) != len(urls) {
+		t.Errorf("CrawlBatch() records = %d, want %d", len(got), len(urls))
+	}
+
+	if max := atomic.LoadInt64(&maxInFlight); max > workers {
+		t.Errorf("CrawlBatch() concurrent requests = %d, want at most %d", max, workers)
+	}
+}
+
+func TestCrawlBatchHandlerError(t *testing.T) {
+	errWrite := errors.New("broken pipe")
+
+	var requests int64
+	c := New(WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt64(&requests, 1)
+		site := &fixture.Site{Pages: map[string]string{req.URL.String(): fixture.Page(fixture.Review("1", fixture.Date))}}
+		return site.RoundTrip(req)
+	})))
+
+	urls := make([]string, 6)
+	for i := range urls {
+		urls[i] = c.ReviewsURL(Company{Name: "Company", ID: strconv.Itoa(i + 1)})
+	}
+
+	h := BatchHandler{
+		Review: func(Company, reviews.Review) error {
+			return errWrite
+		},
+	}
+
+	err := c.CrawlBatch(context.Background(), urls, 1, Options{Options: crawler.Options{MaxPages: 1}}, h)
+	if err != errWrite {
+		t.Fatalf("CrawlBatch() error = %v, want %v", err, errWrite)
+	}
+
+	if n := atomic.LoadInt64(&requests); n != 1 {
+		t.Errorf("CrawlBatch() requests = %d, want 1", n)
+	}
+}
+
+func TestCrawlBatchCancelled(t *testing.T) {
+	site := &fixture.Site{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	urls := []string{
+		"https://www.glassdoor.com/Reviews/Tesla-Reviews-E43129.htm",
+		"https://www.glassdoor.com/Reviews/Goldman-Sachs-Reviews-E2800.htm",
+	}
+
+	var got []string
+	err := New(WithTransport(site)).CrawlBatch(ctx, urls, 1, Options{}, collect(&got))
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("CrawlBatch() error = %v, want *BatchError", err)
+	}
+
+	if len(batchErr.Errors) != len(urls) {
+		t.Fatalf("CrawlBatch() errors = %d, want %d", len(batchErr.Errors), len(urls))
+	}
+
+	for _, companyErr := range batchErr.Errors {
+		if !errors.Is(companyErr, context.Canceled) {
+			t.Errorf("CrawlBatch() error = %v, want %v", companyErr, context.Canceled)
+		}
+	}
+
+	if diff := cmp.Diff(Company{Name: "Tesla", ID: "43129"}, batchErr.Errors[0].Company); diff != "" {
+		t.Errorf("CrawlBatch() company mismatch (-want +got):\n%s", diff)
+	}
+}
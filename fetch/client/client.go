@@ -0,0 +1,416 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/jacoelho/openblind"
+	"github.com/jacoelho/openblind/crawler"
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/query"
+	"github.com/jacoelho/openblind/reviews"
+	"github.com/jacoelho/openblind/section"
+	"golang.org/x/net/html"
+)
+
+const (
+	DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/89.0.4389.114 Safari/537.36"
+	DefaultDomain    = "www.glassdoor.com"
+	DefaultTimeout   = 5 * time.Second
+)
+
+// companyRe matches the reviews and interviews page paths
+// example: /Reviews/Tesla-Reviews-E43129.htm, /Interview/Tesla-Interview-Questions-E43129_P2.htm
+var companyRe = regexp.MustCompile(`^/(?:Reviews|Interview)/(?P<Name>.+?)-(?:Reviews|Interview-Questions)-E(?P<ID>\d+)(?:_P\d+)?\.htm$`)
+
+var ErrUnknownCompany = errors.New("unknown company")
+
+// Company identifies an employer by the name and id used in its urls
+type Company struct {
+	Name string
+	ID   string
+}
+
+// CompanyFromURL returns the company of a reviews or interviews page url
+func CompanyFromURL(rawURL string) (Company, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Company{}, err
+	}
+
+	matches := companyRe.FindStringSubmatch(u.Path)
+	if matches == nil {
+		return Company{}, fmt.Errorf("%s: %w", rawURL, ErrUnknownCompany)
+	}
+
+	return Company{
+		Name: matches[companyRe.SubexpIndex("Name")],
+		ID:   matches[companyRe.SubexpIndex("ID")],
+	}, nil
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the http client used for every request
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTransport sets the round tripper of the http client
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = rt
+	}
+}
+
+// WithUserAgent sets the User-Agent header
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithHeader adds a header sent with every request
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithTimeout sets the timeout of each request, zero means no timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithDomain sets the locale domain companies are fetched from, e.g. www.glassdoor.co.uk
+func WithDomain(domain string) Option {
+	return func(c *Client) {
+		c.domain = domain
+	}
+}
+
+// Client fetches and parses reviews and interviews pages
+type Client struct {
+	// retried and requested count the retries against the budget and the requests growing it,
+	// first for 64-bit atomic alignment
+	retried   int64
+	requested int64
+
+	httpClient *http.Client
+	transport  http.RoundTripper
+	userAgent  string
+	header     http.Header
+	timeout    time.Duration
+	domain     string
+	retry      RetryPolicy
+	limiter    *limiter
+	jar        http.CookieJar
+}
+
+func New(opts ...Option) *Client {
+	c := &Client{
+		httpClient: http.DefaultClient,
+		userAgent:  DefaultUserAgent,
+		header:     make(http.Header),
+		timeout:    DefaultTimeout,
+		domain:     DefaultDomain,
+		retry:      DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.transport != nil || c.jar != nil {
+		hc := *c.httpClient
+		if c.transport != nil {
+			hc.Transport = c.transport
+		}
+		if c.jar != nil {
+			hc.Jar = c.jar
+		}
+		c.httpClient = &hc
+	}
+
+	return c
+}
+
+// Options controls a crawl
+type Options struct {
+	crawler.Options
+
+	// Section of the pages, detected from the url or the first page when auto or empty
+	Section section.Section
+
+	// Query sorts and filters the records
+	Query query.Options
+}
+
+// selectors returns the interview and review selectors, defaulting to the embedded profile
+func (opts Options) selectors() (*openblind.Selectors, *openblind.Selectors) {
+	interviewSelectors := opts.InterviewSelectors
+	if interviewSelectors == nil {
+		interviewSelectors = interviews.DefaultSelectors()
+	}
+
+	reviewSelectors := opts.ReviewSelectors
+	if reviewSelectors == nil {
+		reviewSelectors = reviews.DefaultSelectors()
+	}
+
+	return interviewSelectors, reviewSelectors
+}
+
+// Handler receives the records of a crawl, only the handler of the crawled section is called
+type Handler struct {
+	Review    func(reviews.Review) error
+	Interview func(interviews.Interview) error
+}
+
+// cancelBody releases the request context once the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// Fetch returns the body of the page at rawURL, the caller closes it.
+// Non 2xx responses are returned as *StatusError, or *InterstitialError for challenge pages,
+// transient failures are retried following the retry policy. It can be used as a crawler.Fetcher
+func (c *Client) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	atomic.AddInt64(&c.requested, 1)
+
+	for retry := 0; ; retry++ {
+		body, err := c.fetch(ctx, rawURL)
+		if err == nil {
+			return body, nil
+		}
+
+		delay, ok := c.retryDelay(ctx, err, retry)
+		if !ok {
+			return nil, err
+		}
+
+		if !c.takeRetry() {
+			return nil, fmt.Errorf("retry budget exhausted: %w", err)
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetch makes a single request
+func (c *Client) fetch(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	if c.limiter != nil {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		// wait before starting the request timeout
+		if err := c.limiter.wait(ctx, u.Host); err != nil {
+			return nil, err
+		}
+	}
+
+	cancel := func() {}
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	for key, values := range c.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// challenge pages are served with error statuses, read enough to recognise them,
+		// consent banners are ignored as error pages such as maintenance ones carry them too
+		data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		cancel()
+
+		if kind, found := challengeKind(data); found {
+			return nil, &InterstitialError{URL: rawURL, Kind: kind, StatusCode: resp.StatusCode}
+		}
+
+		return nil, &StatusError{
+			URL:        rawURL,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	return &cancelBody{ReadCloser: resp.Body, cancel: cancel}, nil
+}
+
+// detect returns the section from the url path, failing that from the first page,
+// the returned fetcher serves the first page from memory to avoid fetching it twice
+func (c *Client) detect(ctx context.Context, u *url.URL, opts Options) (section.Section, crawler.Fetcher, error) {
+	if s, found := section.FromURL(u); found {
+		return s, c.Fetch, nil
+	}
+
+	firstPage, err := crawler.PageURL(u.String(), 1)
+	if err != nil {
+		return "", nil, err
+	}
+
+	body, err := c.Fetch(ctx, firstPage)
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := ioutil.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return "", nil, err
+	}
+
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, err
+	}
+
+	interviewSelectors, reviewSelectors := opts.selectors()
+
+	s, err := section.DetectSelectors(root, interviewSelectors, reviewSelectors)
+	if err != nil {
+		return "", nil, checkDetected(firstPage, data, err)
+	}
+
+	cached := func(ctx context.Context, pageURL string) (io.ReadCloser, error) {
+		if pageURL == firstPage {
+			return ioutil.NopCloser(bytes.NewReader(data)), nil
+		}
+		return c.Fetch(ctx, pageURL)
+	}
+
+	return s, cached, nil
+}
+
+// Crawl fetches the pages starting at rawURL calling the handler of their section with each record
+func (c *Client) Crawl(ctx context.Context, rawURL string, opts Options, h Handler) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	u, err = query.Apply(u, opts.Query)
+	if err != nil {
+		return err
+	}
+
+	// records older than Since may be followed by newer ones in any other order
+	if !opts.Query.NewestFirst() {
+		opts.Unsorted = true
+	}
+
+	var fetch crawler.Fetcher = c.Fetch
+
+	s := opts.Section
+	if s == "" || s == section.Auto {
+		s, fetch, err = c.detect(ctx, u, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	// interstitial pages are reported as *InterstitialError instead of failing to find records
+	var check pageCheck
+	fetch = check.fetcher(fetch)
+	h = check.handler(h)
+
+	switch s {
+	case section.Interviews:
+		if h.Interview == nil {
+			return fmt.Errorf("no interview handler: %w", section.ErrUnknownSection)
+		}
+		return check.err(crawler.InterviewsFunc(ctx, fetch, u.String(), opts.Options, h.Interview))
+	case section.Reviews:
+		if h.Review == nil {
+			return fmt.Errorf("no review handler: %w", section.ErrUnknownSection)
+		}
+		return check.err(crawler.ReviewsFunc(ctx, fetch, u.String(), opts.Options, h.Review))
+	default:
+		return fmt.Errorf("%s: %w", s, section.ErrUnknownSection)
+	}
+}
+
+// ReviewsURL returns the first reviews page of the company
+func (c *Client) ReviewsURL(company Company) string {
+	return fmt.Sprintf("https://%s/Reviews/%s-Reviews-E%s.htm", c.domain, company.Name, company.ID)
+}
+
+// InterviewsURL returns the first interviews page of the company
+func (c *Client) InterviewsURL(company Company) string {
+	return fmt.Sprintf("https://%s/Interview/%s-Interview-Questions-E%s.htm", c.domain, company.Name, company.ID)
+}
+
+// FetchReviewsFunc crawls the company reviews calling fn for each review
+func (c *Client) FetchReviewsFunc(ctx context.Context, company Company, opts Options, fn func(reviews.Review) error) error {
+	opts.Section = section.Reviews
+	return c.Crawl(ctx, c.ReviewsURL(company), opts, Handler{Review: fn})
+}
+
+// FetchReviews crawls the company reviews
+func (c *Client) FetchReviews(ctx context.Context, company Company, opts Options) ([]reviews.Review, error) {
+	var result []reviews.Review
+
+	err := c.FetchReviewsFunc(ctx, company, opts, func(review reviews.Review) error {
+		result = append(result, review)
+		return nil
+	})
+
+	return result, err
+}
+
+// FetchInterviewsFunc crawls the company interviews calling fn for each interview
+func (c *Client) FetchInterviewsFunc(ctx context.Context, company Company, opts Options, fn func(interviews.Interview) error) error {
+	opts.Section = section.Interviews
+	return c.Crawl(ctx, c.InterviewsURL(company), opts, Handler{Interview: fn})
+}
+
+// FetchInterviews crawls the company interviews
+func (c *Client) FetchInterviews(ctx context.Context, company Company, opts Options) ([]interviews.Interview, error) {
+	var result []interviews.Interview
+
+	err := c.FetchInterviewsFunc(ctx, company, opts, func(interview interviews.Interview) error {
+		result = append(result, interview)
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jacoelho/openblind/crawler"
+	"github.com/jacoelho/openblind/internal/fixture"
+	"github.com/jacoelho/openblind/query"
+	"github.com/jacoelho/openblind/reviews"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCompanyFromURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    Company
+		wantErr error
+	}{
+		{
+			url:  "https://www.glassdoor.co.uk/Reviews/Tesla-Reviews-E43129.htm",
+			want: Company{Name: "Tesla", ID: "43129"},
+		},
+		{
+			url:  "https://www.glassdoor.com/Reviews/Goldman-Sachs-Reviews-E2800_P3.htm?sort.sortType=RD",
+			want: Company{Name: "Goldman-Sachs", ID: "2800"},
+		},
+		{
+			url:  "https://www.glassdoor.com/Interview/Tesla-Interview-Questions-E43129.htm",
+			want: Company{Name: "Tesla", ID: "43129"},
+		},
+		{
+			url:     "https://www.glassdoor.com/Overview/Working-at-Tesla-EI_IE43129.11,16.htm",
+			wantErr: ErrUnknownCompany,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := CompanyFromURL(tt.url)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompanyFromURL() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("CompanyFromURL() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchHeaders(t *testing.T) {
+	var got http.Header
+
+	c := New(
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			got = req.Header
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+		})),
+		WithUserAgent("openblind-test"),
+		WithHeader("Accept-Language", "en-GB"),
+	)
+
+	body, err := c.Fetch(context.Background(), "https://example.com/")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	body.Close()
+
+	want := http.Header{
+		"User-Agent":      {"openblind-test"},
+		"Accept-Language": {"en-GB"},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Fetch() headers mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithTransportKeepsHTTPClient(t *testing.T) {
+	hc := &http.Client{}
+	site := &fixture.Site{}
+
+	c := New(WithHTTPClient(hc), WithTransport(site))
+
+	if hc.Transport != nil {
+		t.Error("WithTransport() modified the given http client")
+	}
+
+	if c.httpClient.Transport != site {
+		t.Error("WithTransport() transport not set")
+	}
+}
+
+func TestFetchReviews(t *testing.T) {
+	const (
+		page1 = "https://www.glassdoor.co.uk/Reviews/Tesla-Reviews-E43129.htm?filter.employmentStatus=CURRENT"
+		page2 = "https://www.glassdoor.co.uk/Reviews/Tesla-Reviews-E43129_P2.htm?filter.employmentStatus=CURRENT"
+	)
+
+	site := &fixture.Site{Pages: map[string]string{
+		page1: fixture.Page(fixture.Review("1", fixture.Date), fixture.Review("2", fixture.Date)),
+		page2: fixture.Page(fixture.Review("3", fixture.Date)),
+	}}
+
+	c := New(WithTransport(site), WithDomain("www.glassdoor.co.uk"))
+
+	opts := Options{Options: crawler.Options{MaxPages: 2}}
+	opts.Query.EmploymentStatus = "current"
+
+	result, err := c.FetchReviews(context.Background(), Company{Name: "Tesla", ID: "43129"}, opts)
+	if err != nil {
+		t.Fatalf("FetchReviews() error = %v", err)
+	}
+
+	var ids []string
+	for _, r := range result {
+		ids = append(ids, r.ID)
+	}
+
+	if diff := cmp.Diff([]string{"1", "2", "3"}, ids); diff != "" {
+		t.Errorf("FetchReviews() mismatch (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]string{page1, page2}, site.Fetched()); diff != "" {
+		t.Errorf("FetchReviews() fetched mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCrawlDetectsSection(t *testing.T) {
+	const page1 = "https://example.com/Tesla-E43129.htm"
+
+	site := &fixture.Site{Pages: map[string]string{
+		page1: fixture.Page(fixture.Review("1", fixture.Date)),
+	}}
+
+	var ids []string
+	handler := Handler{
+		Review: func(r reviews.Review) error {
+			ids = append(ids, r.ID)
+			return nil
+		},
+	}
+
+	err := New(WithTransport(site)).Crawl(context.Background(), page1, Options{Options: crawler.Options{MaxPages: 1}}, handler)
+	if err != nil {
+		t.Fatalf("Crawl() error = %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"1"}, ids); diff != "" {
+		t.Errorf("Crawl() mismatch (-want +got):\n%s", diff)
+	}
+
+	// the first page is read once for detection and parsing
+	if diff := cmp.Diff([]string{page1}, site.Fetched()); diff != "" {
+		t.Errorf("Crawl() fetched mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCrawlSinceUnsorted(t *testing.T) {
+	const (
+		page1 = "https://www.glassdoor.com/Reviews/Tesla-Reviews-E43129.htm?sort.ascending=false&sort.sortType=OR"
+		page2 = "https://www.glassdoor.com/Reviews/Tesla-Reviews-E43129_P2.htm?sort.ascending=false&sort.sortType=OR"
+	)
+
+	old := strings.Replace(fixture.Review("1", fixture.Date), "Apr 04 2021", "Jan 04 2020", 1)
+
+	site := &fixture.Site{Pages: map[string]string{
+		page1: fixture.Page(old),
+		page2: fixture.Page(fixture.Review("2", fixture.Date)),
+	}}
+
+	opts := Options{Options: crawler.Options{MaxPages: 2, Since: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	opts.Query.Sort = query.SortRating
+
+	result, err := New(WithTransport(site)).FetchReviews(context.Background(), Company{Name: "Tesla", ID: "43129"}, opts)
+	if err != nil {
+		t.Fatalf("FetchReviews() error = %v", err)
+	}
+
+	// sorted by rating an older review does not end the crawl
+	if len(result) != 1 || result[0].ID != "2" {
+		t.Errorf("FetchReviews() = %+v, want review 2", result)
+	}
+}
@@ -0,0 +1,353 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+var ErrInvalidCookies = errors.New("invalid cookies file")
+
+const (
+	netscapeHeader = "# Netscape HTTP Cookie File"
+	httpOnlyPrefix = "#HttpOnly_"
+)
+
+// WithCookieJar sets the cookie jar of the http client
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(c *Client) {
+		c.jar = jar
+	}
+}
+
+// cookie is a stored cookie with the attributes needed to write it back
+type cookie struct {
+	domain   string
+	hostOnly bool
+	path     string
+	secure   bool
+	httpOnly bool
+	// expires is zero for session cookies
+	expires time.Time
+	name    string
+	value   string
+}
+
+func (c *cookie) key() string {
+	return c.domain + ";" + c.path + ";" + c.name
+}
+
+// Jar is a cookie jar that can be loaded from and saved to a Netscape cookies.txt file,
+// as written by curl, wget and browser export extensions. It is safe for concurrent use
+type Jar struct {
+	jar *cookiejar.Jar
+	now func() time.Time
+
+	mu      sync.Mutex
+	cookies map[string]*cookie
+}
+
+func NewJar() *Jar {
+	// cookiejar.New only fails on invalid options
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+
+	return &Jar{
+		jar:     jar,
+		now:     time.Now,
+		cookies: make(map[string]*cookie),
+	}
+}
+
+// LoadJar returns a jar with the cookies stored at path, a missing file gives an empty jar
+func LoadJar(path string) (*Jar, error) {
+	jar := NewJar()
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return jar, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := jar.Load(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return jar, nil
+}
+
+// Cookies implements http.CookieJar
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// SetCookies implements http.CookieJar
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.now()
+	host := strings.ToLower(u.Hostname())
+
+	for _, hc := range cookies {
+		c := &cookie{
+			domain:   host,
+			hostOnly: true,
+			path:     hc.Path,
+			secure:   hc.Secure,
+			httpOnly: hc.HttpOnly,
+			expires:  hc.Expires,
+			name:     hc.Name,
+			value:    hc.Value,
+		}
+
+		if hc.Domain != "" {
+			domain := strings.TrimPrefix(strings.ToLower(hc.Domain), ".")
+			if host != domain && !strings.HasSuffix(host, "."+domain) {
+				// rejected by the jar
+				continue
+			}
+			c.domain, c.hostOnly = domain, false
+		}
+
+		if c.path == "" || c.path[0] != '/' {
+			c.path = defaultPath(u.Path)
+		}
+
+		switch {
+		case hc.MaxAge < 0:
+			c.expires = now
+		case hc.MaxAge > 0:
+			c.expires = now.Add(time.Duration(hc.MaxAge) * time.Second)
+		}
+
+		if !c.expires.IsZero() && !c.expires.After(now) {
+			delete(j.cookies, c.key())
+			continue
+		}
+
+		j.cookies[c.key()] = c
+	}
+}
+
+// defaultPath is the cookie path used when none is given, the directory of the request path
+func defaultPath(p string) string {
+	if p == "" || p[0] != '/' {
+		return "/"
+	}
+	return path.Dir(p)
+}
+
+// Load adds the cookies of a Netscape cookies.txt file, expired cookies are skipped
+func (j *Jar) Load(r io.Reader) error {
+	now := j.now()
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+
+		httpOnly := strings.HasPrefix(text, httpOnlyPrefix)
+		if httpOnly {
+			text = strings.TrimPrefix(text, httpOnlyPrefix)
+		}
+
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("line %d: expected 7 fields, found %d: %w", line, len(fields), ErrInvalidCookies)
+		}
+
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: expiry %q: %w", line, fields[4], ErrInvalidCookies)
+		}
+
+		c := cookie{
+			domain:   strings.ToLower(fields[0]),
+			hostOnly: !strings.EqualFold(fields[1], "TRUE") && !strings.HasPrefix(fields[0], "."),
+			path:     fields[2],
+			secure:   strings.EqualFold(fields[3], "TRUE"),
+			httpOnly: httpOnly,
+			name:     fields[5],
+			value:    fields[6],
+		}
+
+		// zero marks a session cookie
+		if expiry > 0 {
+			c.expires = time.Unix(expiry, 0)
+		}
+
+		j.add(c, now)
+	}
+
+	return scanner.Err()
+}
+
+// browserCookie is a cookie exported by browser extensions as json
+type browserCookie struct {
+	Domain         string  `json:"domain"`
+	HostOnly       bool    `json:"hostOnly"`
+	Path           string  `json:"path"`
+	Secure         bool    `json:"secure"`
+	HTTPOnly       bool    `json:"httpOnly"`
+	Session        bool    `json:"session"`
+	ExpirationDate float64 `json:"expirationDate"`
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+}
+
+// Import adds cookies exported from a browser, either a Netscape cookies.txt file
+// or the json array written by cookie export extensions
+func (j *Jar) Import(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '[' {
+		return j.Load(bytes.NewReader(data))
+	}
+
+	var exported []browserCookie
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return fmt.Errorf("%s: %w", err.Error(), ErrInvalidCookies)
+	}
+
+	now := j.now()
+	for _, bc := range exported {
+		c := cookie{
+			domain:   strings.ToLower(bc.Domain),
+			hostOnly: bc.HostOnly,
+			path:     bc.Path,
+			secure:   bc.Secure,
+			httpOnly: bc.HTTPOnly,
+			name:     bc.Name,
+			value:    bc.Value,
+		}
+
+		if !bc.Session && bc.ExpirationDate > 0 {
+			c.expires = time.Unix(int64(bc.ExpirationDate), 0)
+		}
+
+		j.add(c, now)
+	}
+
+	return nil
+}
+
+// add stores a cookie read from a file, as if set by its domain
+func (j *Jar) add(c cookie, now time.Time) {
+	if !c.expires.IsZero() && !c.expires.After(now) {
+		return
+	}
+
+	domain := strings.TrimPrefix(c.domain, ".")
+	if c.path == "" {
+		c.path = "/"
+	}
+
+	hc := &http.Cookie{
+		Name:     c.name,
+		Value:    c.value,
+		Path:     c.path,
+		Secure:   c.secure,
+		HttpOnly: c.httpOnly,
+		Expires:  c.expires,
+	}
+
+	if !c.hostOnly {
+		hc.Domain = domain
+	}
+
+	j.SetCookies(&url.URL{Scheme: "https", Host: domain, Path: c.path}, []*http.Cookie{hc})
+}
+
+// Save writes the cookies that have not expired in Netscape cookies.txt format
+func (j *Jar) Save(w io.Writer) error {
+	j.mu.Lock()
+	cookies := make([]*cookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		cookies = append(cookies, c)
+	}
+	j.mu.Unlock()
+
+	sort.Slice(cookies, func(a, b int) bool {
+		return cookies[a].key() < cookies[b].key()
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s\n\n", netscapeHeader)
+
+	now := j.now()
+	for _, c := range cookies {
+		if !c.expires.IsZero() && !c.expires.After(now) {
+			continue
+		}
+
+		prefix, domain, includeSubdomains := "", c.domain, "FALSE"
+		if c.httpOnly {
+			prefix = httpOnlyPrefix
+		}
+		if !c.hostOnly {
+			domain, includeSubdomains = "."+c.domain, "TRUE"
+		}
+
+		secure := "FALSE"
+		if c.secure {
+			secure = "TRUE"
+		}
+
+		var expiry int64
+		if !c.expires.IsZero() {
+			expiry = c.expires.Unix()
+		}
+
+		fmt.Fprintf(bw, "%s%s\t%s\t%s\t%s\t%d\t%s\t%s\n", prefix, domain, includeSubdomains, c.path, secure, expiry, c.name, c.value)
+	}
+
+	return bw.Flush()
+}
+
+// SaveFile writes the cookies to path replacing it atomically, the file is only readable by the user
+func (j *Jar) SaveFile(path string) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := j.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var jarNow = time.Unix(1617537600, 0)
+
+func newTestJar() *Jar {
+	jar := NewJar()
+	jar.now = func() time.Time { return jarNow }
+	return jar
+}
+
+// cookieHeader returns the cookies the jar sends to rawURL
+func cookieHeader(t *testing.T, jar *Jar, rawURL string) string {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pairs []string
+	for _, c := range jar.Cookies(u) {
+		pairs = append(pairs, c.Name+"="+c.Value)
+	}
+	return strings.Join(pairs, "; ")
+}
+
+const cookiesFile = `# Netscape HTTP Cookie File
+
+.glassdoor.com	TRUE	/	TRUE	1924992000	GSESSIONID	abc
+#HttpOnly_www.glassdoor.com	FALSE	/	TRUE	0	cf_clearance	xyz
+www.glassdoor.com	FALSE	/Reviews	FALSE	1924992000	filter	current
+.glassdoor.com	TRUE	/	FALSE	1000	expired	old
+`
+
+func TestJarLoadSave(t *testing.T) {
+	jar := newTestJar()
+	if err := jar.Load(strings.NewReader(cookiesFile)); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := jar.Save(&buf); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	want := `# Netscape HTTP Cookie File
+
+.glassdoor.com	TRUE	/	TRUE	1924992000	GSESSIONID	abc
+#HttpOnly_www.glassdoor.com	FALSE	/	TRUE	0	cf_clearance	xyz
+www.glassdoor.com	FALSE	/Reviews	FALSE	1924992000	filter	current
+`
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("Save() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestJarCookies(t *testing.T) {
+	jar := newTestJar()
+	if err := jar.Load(strings.NewReader(cookiesFile)); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://www.glassdoor.com/Reviews/Tesla-Reviews-E43129.htm", want: "filter=current; GSESSIONID=abc; cf_clearance=xyz"},
+		{url: "https://www.glassdoor.com/Interview/Tesla-Interview-Questions-E43129.htm", want: "GSESSIONID=abc; cf_clearance=xyz"},
+		{url: "https://api.glassdoor.com/", want: "GSESSIONID=abc"},
+		{url: "http://www.glassdoor.com/", want: ""},
+		{url: "https://www.example.com/", want: ""},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, cookieHeader(t, jar, tt.url)); diff != "" {
+			t.Errorf("Cookies(%s) mismatch (-want +got):\n%s", tt.url, diff)
+		}
+	}
+}
+
+func TestJarLoadInvalid(t *testing.T) {
+	tests := []string{
+		"www.glassdoor.com\tFALSE\t/\n",
+		"www.glassdoor.com\tFALSE\t/\tFALSE\tnever\tname\tvalue\n",
+	}
+
+	for _, input := range tests {
+		if err := newTestJar().Load(strings.NewReader(input)); !errors.Is(err, ErrInvalidCookies) {
+			t.Errorf("Load(%q) error = %v, want %v", input, err, ErrInvalidCookies)
+		}
+	}
+}
+
+func TestJarImport(t *testing.T) {
+	exported := `[
+	{"domain": ".glassdoor.com", "hostOnly": false, "path": "/", "secure": true, "httpOnly": true, "session": false, "expirationDate": 1924992000.5, "name": "GSESSIONID", "value": "abc"},
+	{"domain": "www.glassdoor.com", "hostOnly": true, "path": "/", "secure": true, "httpOnly": false, "session": true, "name": "cf_clearance", "value": "xyz"},
+	{"domain": ".glassdoor.com", "hostOnly": false, "path": "/", "expirationDate": 1000, "name": "expired", "value": "old"}
+]`
+
+	jar := newTestJar()
+	if err := jar.Import(strings.NewReader(exported)); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if diff := cmp.Diff("GSESSIONID=abc; cf_clearance=xyz", cookieHeader(t, jar, "https://www.glassdoor.com/")); diff != "" {
+		t.Errorf("Import() mismatch (-want +got):\n%s", diff)
+	}
+
+	// netscape files are imported as well
+	jar = newTestJar()
+	if err := jar.Import(strings.NewReader(cookiesFile)); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if diff := cmp.Diff("GSESSIONID=abc", cookieHeader(t, jar, "https://api.glassdoor.com/")); diff != "" {
+		t.Errorf("Import() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestJarPersistsServerCookies(t *testing.T) {
+	var received []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("Cookie"))
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+		http.SetCookie(w, &http.Cookie{Name: "visitor", Value: "v1", Path: "/", MaxAge: 3600})
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+
+	for i := 0; i < 2; i++ {
+		jar, err := LoadJar(path)
+		if err != nil {
+			t.Fatalf("LoadJar() error = %v", err)
+		}
+
+		body, err := New(WithCookieJar(jar)).Fetch(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		body.Close()
+
+		if err := jar.SaveFile(path); err != nil {
+			t.Fatalf("SaveFile() error = %v", err)
+		}
+	}
+
+	// the second run sends the cookies saved by the first
+	if diff := cmp.Diff([]string{"", "session=s1; visitor=v1"}, received); diff != "" {
+		t.Errorf("cookies mismatch (-want +got):\n%s", diff)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("SaveFile() permissions = %v, want %v", perm, os.FileMode(0600))
+	}
+}
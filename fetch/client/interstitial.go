@@ -0,0 +1,213 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/jacoelho/openblind/crawler"
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+	"github.com/jacoelho/openblind/section"
+)
+
+var ErrInterstitial = errors.New("interstitial page")
+
+// InterstitialError is returned when a bot challenge, captcha or consent page
+// is served instead of the requested page
+type InterstitialError struct {
+	URL  string
+	Kind string
+	// StatusCode is the response status, challenges are often served as 403 or 503
+	StatusCode int
+}
+
+func (e *InterstitialError) Error() string {
+	return fmt.Sprintf("%s: %s page served instead of content (status %d), import cookies from a browser session", e.URL, e.Kind, e.StatusCode)
+}
+
+func (e *InterstitialError) Is(target error) bool {
+	return target == ErrInterstitial
+}
+
+const (
+	kindChallenge = "bot challenge"
+	kindCaptcha   = "captcha"
+	kindConsent   = "consent"
+)
+
+// interstitialMarkers are found in the markup of interstitial pages,
+// consent markers also appear in banners of regular pages
+var interstitialMarkers = []struct {
+	kind   string
+	marker []byte
+}{
+	{kind: kindChallenge, marker: []byte("cf-browser-verification")},
+	{kind: kindChallenge, marker: []byte("challenge-platform")},
+	{kind: kindChallenge, marker: []byte("_cf_chl_opt")},
+	{kind: kindChallenge, marker: []byte("<title>Just a moment...</title>")},
+	{kind: kindCaptcha, marker: []byte("px-captcha")},
+	{kind: kindCaptcha, marker: []byte("captcha-delivery.com")},
+	{kind: kindCaptcha, marker: []byte("g-recaptcha")},
+	{kind: kindCaptcha, marker: []byte("h-captcha")},
+	{kind: kindConsent, marker: []byte("onetrust-consent-sdk")},
+	{kind: kindConsent, marker: []byte("consent-page")},
+	{kind: kindConsent, marker: []byte("cookie-consent")},
+}
+
+// interstitialKind returns the kind of the first marker found in the page
+func interstitialKind(data []byte) (string, bool) {
+	return findMarker(data, true)
+}
+
+// challengeKind is like interstitialKind ignoring consent markers,
+// the markers left are never found on regular pages
+func challengeKind(data []byte) (string, bool) {
+	return findMarker(data, false)
+}
+
+func findMarker(data []byte, consent bool) (string, bool) {
+	for _, m := range interstitialMarkers {
+		if m.kind == kindConsent && !consent {
+			continue
+		}
+
+		if bytes.Contains(data, m.marker) {
+			return m.kind, true
+		}
+	}
+	return "", false
+}
+
+// maxMarkerLen is the length of the longest marker, kept between reads to find markers split across them
+var maxMarkerLen = func() int {
+	result := 0
+	for _, m := range interstitialMarkers {
+		if len(m.marker) > result {
+			result = len(m.marker)
+		}
+	}
+	return result
+}()
+
+// markerReader scans the body for interstitial markers while it is read
+type markerReader struct {
+	io.ReadCloser
+	check *pageCheck
+	buf   []byte
+}
+
+func (m *markerReader) Read(p []byte) (int, error) {
+	n, err := m.ReadCloser.Read(p)
+	if n == 0 || m.check.found() {
+		return n, err
+	}
+
+	m.buf = append(m.buf, p[:n]...)
+	if kind, found := interstitialKind(m.buf); found {
+		m.check.mark(kind)
+	}
+
+	if keep := maxMarkerLen - 1; len(m.buf) > keep {
+		m.buf = append(m.buf[:0], m.buf[len(m.buf)-keep:]...)
+	}
+
+	return n, err
+}
+
+// pageCheck tracks the markers and records of the last page of a crawl, pages are
+// streamed to the parser and only reported as interstitial when they yield no records
+type pageCheck struct {
+	mu      sync.Mutex
+	url     string
+	kind    string
+	records int
+}
+
+func (c *pageCheck) found() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.kind != ""
+}
+
+func (c *pageCheck) mark(kind string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.kind = kind
+}
+
+func (c *pageCheck) record() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.records++
+}
+
+// fetcher wraps fetch to scan every page for markers as the parser reads it
+func (c *pageCheck) fetcher(fetch crawler.Fetcher) crawler.Fetcher {
+	return func(ctx context.Context, pageURL string) (io.ReadCloser, error) {
+		body, err := fetch(ctx, pageURL)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.url, c.kind, c.records = pageURL, "", 0
+		c.mu.Unlock()
+
+		return &markerReader{ReadCloser: body, check: c}, nil
+	}
+}
+
+// handler wraps h to count the records of the last page
+func (c *pageCheck) handler(h Handler) Handler {
+	var result Handler
+
+	if h.Review != nil {
+		result.Review = func(r reviews.Review) error {
+			c.record()
+			return h.Review(r)
+		}
+	}
+
+	if h.Interview != nil {
+		result.Interview = func(i interviews.Interview) error {
+			c.record()
+			return h.Interview(i)
+		}
+	}
+
+	return result
+}
+
+// err returns an *InterstitialError in place of err when the crawl failed
+// on a page with interstitial markers and no records
+func (c *pageCheck) err(err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil || c.kind == "" || c.records > 0 {
+		return err
+	}
+
+	return &InterstitialError{URL: c.url, Kind: c.kind, StatusCode: http.StatusOK}
+}
+
+// checkDetected returns an *InterstitialError in place of the failure to detect the section
+// of a page with interstitial markers and neither the reviews nor the interview list
+func checkDetected(pageURL string, data []byte, err error) error {
+	var detectErr *section.DetectError
+	if !errors.As(err, &detectErr) || len(detectErr.Found) > 0 {
+		return err
+	}
+
+	kind, found := interstitialKind(data)
+	if !found {
+		return err
+	}
+
+	return &InterstitialError{URL: pageURL, Kind: kind, StatusCode: http.StatusOK}
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+
+	"github.com/jacoelho/openblind/crawler"
+	"github.com/jacoelho/openblind/internal/fixture"
+	"github.com/jacoelho/openblind/reviews"
+)
+
+const (
+	challengePage = `<html><head><title>Just a moment...</title></head><body><div id="cf-browser-verification"></div></body></html>`
+	consentPage   = `<html><body><div id="onetrust-consent-sdk"><p>We value your privacy</p></div></body></html>`
+	consentBanner = `<div id="onetrust-consent-sdk"></div>`
+)
+
+func TestFetchChallenge(t *testing.T) {
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(challengePage))
+	}))
+	defer srv.Close()
+
+	_, err := New(WithRetryPolicy(testRetryPolicy)).Fetch(context.Background(), srv.URL)
+
+	var interstitialErr *InterstitialError
+	if !errors.As(err, &interstitialErr) {
+		t.Fatalf("Fetch() error = %v, want *InterstitialError", err)
+	}
+
+	if !errors.Is(err, ErrInterstitial) {
+		t.Errorf("Fetch() error = %v, want %v", err, ErrInterstitial)
+	}
+
+	if interstitialErr.Kind != kindChallenge || interstitialErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Fetch() = %+v, want %s with status %d", interstitialErr, kindChallenge, http.StatusServiceUnavailable)
+	}
+
+	// challenges are not solved by retrying
+	if got := atomic.LoadInt64(&requests); got != 1 {
+		t.Errorf("Fetch() requests = %d, want 1", got)
+	}
+}
+
+func TestFetchRetriesConsentBanner(t *testing.T) {
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`<html><body>` + consentBanner + `<p>Down for maintenance</p></body></html>`))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	body, err := New(WithRetryPolicy(testRetryPolicy)).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	body.Close()
+
+	if got := atomic.LoadInt64(&requests); got != 2 {
+		t.Errorf("Fetch() requests = %d, want 2", got)
+	}
+}
+
+func TestFetchConsentBannerStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(consentPage))
+	}))
+	defer srv.Close()
+
+	_, err := New(WithRetryPolicy(testRetryPolicy)).Fetch(context.Background(), srv.URL)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Fetch() error = %v, want status %d", err, http.StatusNotFound)
+	}
+}
+
+func TestCrawlInterstitial(t *testing.T) {
+	const pageURL = "https://www.glassdoor.com/Reviews/Tesla-Reviews-E43129.htm"
+
+	tests := []struct {
+		name     string
+		page     string
+		detect   bool
+		wantKind string
+	}{
+		{
+			name:     "consent page",
+			page:     consentPage,
+			wantKind: kindConsent,
+		},
+		{
+			name:     "consent page detecting section",
+			page:     consentPage,
+			detect:   true,
+			wantKind: kindConsent,
+		},
+		{
+			name: "consent banner on reviews page",
+			page: fixture.Page(fixture.Review("1", fixture.Date), consentBanner),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := &fixture.Site{Pages: map[string]string{pageURL: tt.page}}
+
+			rawURL := pageURL
+			if tt.detect {
+				// without the section in the path the first page is fetched to detect it
+				site.Pages["https://www.glassdoor.com/Tesla-E43129.htm"] = tt.page
+				rawURL = "https://www.glassdoor.com/Tesla-E43129.htm"
+			}
+
+			handler := Handler{Review: func(reviews.Review) error { return nil }}
+			err := New(WithTransport(site)).Crawl(context.Background(), rawURL, Options{Options: crawler.Options{MaxPages: 1}}, handler)
+
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Fatalf("Crawl() error = %v", err)
+				}
+				return
+			}
+
+			var interstitialErr *InterstitialError
+			if !errors.As(err, &interstitialErr) {
+				t.Fatalf("Crawl() error = %v, want *InterstitialError", err)
+			}
+
+			if interstitialErr.Kind != tt.wantKind {
+				t.Errorf("Crawl() kind = %s, want %s", interstitialErr.Kind, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestMarkerReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		wantKind string
+	}{
+		{
+			name:     "marker split across reads",
+			page:     consentPage,
+			wantKind: kindConsent,
+		},
+		{
+			name:     "marker at the end",
+			page:     strings.Repeat("x", 4096) + challengePage,
+			wantKind: kindChallenge,
+		},
+		{
+			name: "no marker",
+			page: fixture.Page(fixture.Review("1", fixture.Date)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var check pageCheck
+
+			body := ioutil.NopCloser(iotest.OneByteReader(strings.NewReader(tt.page)))
+			r := &markerReader{ReadCloser: body, check: &check}
+
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+
+			if string(data) != tt.page {
+				t.Errorf("ReadAll() = %q, want the page unchanged", data)
+			}
+
+			if check.kind != tt.wantKind {
+				t.Errorf("markerReader kind = %q, want %q", check.kind, tt.wantKind)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RateLimit spaces the requests made to the same host
+type RateLimit struct {
+	// Rate is the sustained number of requests per second per host, zero means no limit
+	Rate float64
+
+	// Burst is the number of requests allowed back to back, at least one
+	Burst int
+
+	// Delay is the upper bound of a random pause added before every request
+	Delay time.Duration
+}
+
+// WithRateLimit limits the requests per host, the limit is shared by every
+// goroutine using the client
+func WithRateLimit(limit RateLimit) Option {
+	return func(c *Client) {
+		c.limiter = newLimiter(limit)
+	}
+}
+
+// bucket is the token bucket of a host, tokens go negative while requests are queued
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter holds a token bucket per host
+type limiter struct {
+	limit RateLimit
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	return &limiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// reserve takes a token for host returning how long to wait before using it
+func (l *limiter) reserve(host string) time.Duration {
+	if l.limit.Rate <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	b, found := l.buckets[host]
+	if !found {
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[host] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.limit.Rate
+	if burst := float64(l.limit.Burst); b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / l.limit.Rate * float64(time.Second))
+}
+
+// jitter returns the random pause before a request
+func (l *limiter) jitter() time.Duration {
+	if l.limit.Delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(l.limit.Delay) + 1))
+}
+
+// wait blocks until a request to host is allowed or ctx is done
+func (l *limiter) wait(ctx context.Context, host string) error {
+	d := l.reserve(host) + l.jitter()
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	return sleep(ctx, d)
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// timestampServer records the time every request arrives
+type timestampServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	timestamps []time.Time
+}
+
+func newTimestampServer(t *testing.T) *timestampServer {
+	t.Helper()
+
+	srv := &timestampServer{}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.mu.Lock()
+		srv.timestamps = append(srv.timestamps, time.Now())
+		srv.mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func (s *timestampServer) sorted() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := append([]time.Time(nil), s.timestamps...)
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+func fetchAll(t *testing.T, c *Client, urls ...string) {
+	t.Helper()
+
+	for _, u := range urls {
+		body, err := c.Fetch(context.Background(), u)
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		body.Close()
+	}
+}
+
+func TestRateLimitSharedAcrossWorkers(t *testing.T) {
+	const (
+		workers   = 3
+		perWorker = 3
+		interval  = 50 * time.Millisecond
+
+		// timers may fire slightly early relative to the server clock
+		tolerance = 5 * time.Millisecond
+	)
+
+	srv := newTimestampServer(t)
+	c := New(WithRateLimit(RateLimit{Rate: 20, Burst: 2}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				body, err := c.Fetch(context.Background(), srv.URL)
+				if err != nil {
+					t.Errorf("Fetch() error = %v", err)
+					return
+				}
+				body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	timestamps := srv.sorted()
+	if len(timestamps) != workers*perWorker {
+		t.Fatalf("requests = %d, want %d", len(timestamps), workers*perWorker)
+	}
+
+	// the burst goes out at once, every following request waits for a new token
+	for i := 2; i < len(timestamps); i++ {
+		want := time.Duration(i-1)*interval - tolerance
+		if got := timestamps[i].Sub(timestamps[0]); got < want {
+			t.Errorf("request %d after %v, want at least %v", i, got, want)
+		}
+	}
+}
+
+func TestRateLimitPerHost(t *testing.T) {
+	first := newTimestampServer(t)
+	second := newTimestampServer(t)
+
+	c := New(WithRateLimit(RateLimit{Rate: 10, Burst: 1}))
+
+	start := time.Now()
+	fetchAll(t, c, first.URL, second.URL, first.URL, second.URL)
+	elapsed := time.Since(start)
+
+	// each host waits one interval, a single bucket would wait three
+	if elapsed < 90*time.Millisecond || elapsed > 250*time.Millisecond {
+		t.Errorf("elapsed = %v, want about 100ms", elapsed)
+	}
+}
+
+func TestRateLimitCancelled(t *testing.T) {
+	srv := newTimestampServer(t)
+	c := New(WithRateLimit(RateLimit{Rate: 0.01, Burst: 1}))
+
+	fetchAll(t, c, srv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.Fetch(ctx, srv.URL); err != context.DeadlineExceeded {
+		t.Errorf("Fetch() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if got := len(srv.sorted()); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestLimiterReserve(t *testing.T) {
+	start := time.Date(2021, 4, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		limit RateLimit
+		at    []time.Duration
+		want  []time.Duration
+	}{
+		{
+			name:  "no limit",
+			limit: RateLimit{},
+			at:    []time.Duration{0, 0, 0},
+			want:  []time.Duration{0, 0, 0},
+		},
+		{
+			name:  "queued requests",
+			limit: RateLimit{Rate: 2},
+			at:    []time.Duration{0, 0, 0},
+			want:  []time.Duration{0, 500 * time.Millisecond, time.Second},
+		},
+		{
+			name:  "burst",
+			limit: RateLimit{Rate: 1, Burst: 2},
+			at:    []time.Duration{0, 0, 0, 0},
+			want:  []time.Duration{0, 0, time.Second, 2 * time.Second},
+		},
+		{
+			name:  "refills up to burst",
+			limit: RateLimit{Rate: 1, Burst: 2},
+			at:    []time.Duration{0, 0, time.Minute, time.Minute, time.Minute},
+			want:  []time.Duration{0, 0, 0, 0, time.Second},
+		},
+		{
+			name:  "partial refill",
+			limit: RateLimit{Rate: 1},
+			at:    []time.Duration{0, 250 * time.Millisecond},
+			want:  []time.Duration{0, 750 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLimiter(tt.limit)
+
+			for i, at := range tt.at {
+				now := start.Add(at)
+				l.now = func() time.Time { return now }
+
+				if got := l.reserve("example.com"); got != tt.want[i] {
+					t.Errorf("reserve() #%d = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterJitter(t *testing.T) {
+	l := newLimiter(RateLimit{Delay: 10 * time.Millisecond})
+
+	for i := 0; i < 100; i++ {
+		if got := l.jitter(); got < 0 || got > 10*time.Millisecond {
+			t.Fatalf("jitter() = %v, want within [0, 10ms]", got)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRetryPolicy retries throttled and unavailable pages a few times,
+// allowing 20 retries plus one every 10 requests
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:  3,
+	Budget:      20,
+	BudgetRatio: 0.1,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+}
+
+// RetryPolicy controls how transient failures are retried
+type RetryPolicy struct {
+	// MaxRetries is the number of retries per request, zero disables retrying
+	MaxRetries int
+
+	// Budget caps the retries across every request of the client,
+	// zero together with a zero BudgetRatio means no cap
+	Budget int
+
+	// BudgetRatio adds retries to the budget for every request made, so long running
+	// clients keep retrying while a burst of failures still exhausts the budget
+	BudgetRatio float64
+
+	// MinBackoff is the delay before the first retry, doubled on every retry
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the delay, a longer Retry-After is returned as an error instead of waited for
+	MaxBackoff time.Duration
+}
+
+// StatusError is returned when a page responds with a non 2xx status
+type StatusError struct {
+	URL        string
+	StatusCode int
+
+	// RetryAfter is the delay requested by the server, zero when missing
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Temporary reports whether the request may succeed when retried
+func (e *StatusError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// WithRetryPolicy sets how transient failures are retried
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// parseRetryAfter reads the Retry-After header, either delay seconds or an http date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
+
+// retryable reports whether err is transient, timeouts only count when ctx is still alive
+func retryable(ctx context.Context, err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+
+	var netErr net.Error
+	return ctx.Err() == nil && errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// backoff returns the jittered delay before the given retry, counting from zero
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.MinBackoff
+	for i := 0; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	// equal jitter keeps at least half the delay while spreading concurrent retries
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// retryDelay returns how long to wait before retrying err, false when it should not be retried
+func (c *Client) retryDelay(ctx context.Context, err error, retry int) (time.Duration, bool) {
+	if retry >= c.retry.MaxRetries || !retryable(ctx, err) {
+		return 0, false
+	}
+
+	delay := c.retry.backoff(retry)
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		if c.retry.MaxBackoff > 0 && statusErr.RetryAfter > c.retry.MaxBackoff {
+			return 0, false
+		}
+
+		if statusErr.RetryAfter > delay {
+			delay = statusErr.RetryAfter
+		}
+	}
+
+	return delay, true
+}
+
+// takeRetry consumes one retry from the client budget, grown by the requests made so far
+func (c *Client) takeRetry() bool {
+	if c.retry.Budget <= 0 && c.retry.BudgetRatio <= 0 {
+		return true
+	}
+
+	budget := int64(c.retry.Budget) + int64(c.retry.BudgetRatio*float64(atomic.LoadInt64(&c.requested)))
+	if atomic.AddInt64(&c.retried, 1) <= budget {
+		return true
+	}
+
+	// a refused retry is not spent
+	atomic.AddInt64(&c.retried, -1)
+	return false
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,257 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer responds with the given statuses in turn, then with 200
+func flakyServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *int64) {
+	t.Helper()
+
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&requests, 1)
+		if int(n) <= len(statuses) {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+var testRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: time.Millisecond,
+	MaxBackoff: 10 * time.Millisecond,
+}
+
+func TestFetchRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		header       http.Header
+		statuses     []int
+		wantStatus   int
+		wantRequests int64
+	}{
+		{
+			name:         "recovers from transient failures",
+			policy:       testRetryPolicy,
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			wantRequests: 3,
+		},
+		{
+			name:         "gives up after max retries",
+			policy:       testRetryPolicy,
+			statuses:     []int{502, 502, 502, 502, 502},
+			wantStatus:   http.StatusBadGateway,
+			wantRequests: 4,
+		},
+		{
+			name:         "does not retry client errors",
+			policy:       testRetryPolicy,
+			statuses:     []int{http.StatusNotFound},
+			wantStatus:   http.StatusNotFound,
+			wantRequests: 1,
+		},
+		{
+			name:         "does not wait for retry after longer than max backoff",
+			policy:       testRetryPolicy,
+			header:       http.Header{"Retry-After": {"120"}},
+			statuses:     []int{http.StatusTooManyRequests},
+			wantStatus:   http.StatusTooManyRequests,
+			wantRequests: 1,
+		},
+		{
+			name:         "retrying disabled",
+			policy:       RetryPolicy{},
+			statuses:     []int{http.StatusServiceUnavailable},
+			wantStatus:   http.StatusServiceUnavailable,
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := flakyServer(t, tt.header, tt.statuses...)
+
+			c := New(WithRetryPolicy(tt.policy))
+
+			body, err := c.Fetch(context.Background(), srv.URL)
+
+			var statusErr *StatusError
+			switch {
+			case tt.wantStatus == 0 && err != nil:
+				t.Fatalf("Fetch() error = %v", err)
+			case tt.wantStatus == 0:
+				got, _ := ioutil.ReadAll(body)
+				body.Close()
+				if string(got) != "ok" {
+					t.Errorf("Fetch() body = %q, want %q", got, "ok")
+				}
+			case !errors.As(err, &statusErr):
+				t.Fatalf("Fetch() error = %v, want *StatusError", err)
+			case statusErr.StatusCode != tt.wantStatus:
+				t.Errorf("Fetch() status = %d, want %d", statusErr.StatusCode, tt.wantStatus)
+			}
+
+			if got := atomic.LoadInt64(requests); got != tt.wantRequests {
+				t.Errorf("Fetch() requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestFetchRetryAfter(t *testing.T) {
+	srv, requests := flakyServer(t, http.Header{"Retry-After": {"1"}}, http.StatusServiceUnavailable)
+
+	policy := testRetryPolicy
+	policy.MaxBackoff = 2 * time.Second
+
+	start := time.Now()
+
+	body, err := New(WithRetryPolicy(policy)).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	body.Close()
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Fetch() retried after %v, want at least 1s", elapsed)
+	}
+
+	if got := atomic.LoadInt64(requests); got != 2 {
+		t.Errorf("Fetch() requests = %d, want 2", got)
+	}
+}
+
+func TestFetchRetryBudget(t *testing.T) {
+	srv, requests := flakyServer(t, nil, 503, 503, 503, 503, 503, 503)
+
+	policy := testRetryPolicy
+	policy.Budget = 2
+
+	c := New(WithRetryPolicy(policy))
+
+	// the first fetch spends the whole budget, the second fails without retrying
+	for i := 0; i < 2; i++ {
+		_, err := c.Fetch(context.Background(), srv.URL)
+
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("Fetch() error = %v, want *StatusError", err)
+		}
+	}
+
+	if got := atomic.LoadInt64(requests); got != 4 {
+		t.Errorf("Fetch() requests = %d, want 4", got)
+	}
+}
+
+func TestFetchRetryBudgetRatio(t *testing.T) {
+	srv, requests := flakyServer(t, nil, 503, 200, 503, 200, 503)
+
+	policy := testRetryPolicy
+	policy.Budget = 1
+	policy.BudgetRatio = 0.5
+
+	c := New(WithRetryPolicy(policy))
+
+	// the budget grows by one retry every two requests, the third retry goes over it
+	for i := 0; i < 2; i++ {
+		body, err := c.Fetch(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		body.Close()
+	}
+
+	_, err := c.Fetch(context.Background(), srv.URL)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Fetch() error = %v, want *StatusError", err)
+	}
+
+	if got := atomic.LoadInt64(requests); got != 5 {
+		t.Errorf("Fetch() requests = %d, want 5", got)
+	}
+}
+
+func TestFetchRetryCancelled(t *testing.T) {
+	srv, _ := flakyServer(t, nil, 503)
+
+	policy := testRetryPolicy
+	policy.MinBackoff = time.Minute
+	policy.MaxBackoff = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := New(WithRetryPolicy(policy)).Fetch(ctx, srv.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Fetch() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 4, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "30", want: 30 * time.Second},
+		{value: "-1", want: 0},
+		{value: "Sun, 04 Apr 2021 12:01:00 GMT", want: time.Minute},
+		{value: "Sun, 04 Apr 2021 11:00:00 GMT", want: 0},
+		{value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{retry: 0, max: 100 * time.Millisecond},
+		{retry: 1, max: 200 * time.Millisecond},
+		{retry: 3, max: 800 * time.Millisecond},
+		{retry: 4, max: time.Second},
+		{retry: 10, max: time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if got := policy.backoff(tt.retry); got < tt.max/2 || got > tt.max {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", tt.retry, got, tt.max/2, tt.max)
+			}
+		}
+	}
+
+	if got := (RetryPolicy{}).backoff(3); got != 0 {
+		t.Errorf("backoff() = %v, want 0", got)
+	}
+}
@@ -1,12 +1,48 @@
 package openblind
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 
 	"golang.org/x/net/html"
 )
 
-type Matcher func(*html.Node) bool
+// Matcher reports whether a node matches, String describes what is being matched.
+// It replaced func(*html.Node) bool, wrap such functions in MatcherFunc
+type Matcher interface {
+	Match(*html.Node) bool
+	String() string
+}
+
+// MatcherFunc adapts an ordinary function to a Matcher
+type MatcherFunc func(*html.Node) bool
+
+func (f MatcherFunc) Match(n *html.Node) bool {
+	return f(n)
+}
+
+func (f MatcherFunc) String() string {
+	return "func"
+}
+
+type describedMatcher struct {
+	fn          func(*html.Node) bool
+	description string
+}
+
+func (m describedMatcher) Match(n *html.Node) bool {
+	return m.fn(n)
+}
+
+func (m describedMatcher) String() string {
+	return m.description
+}
+
+// NewMatcher returns a Matcher using fn described by description
+func NewMatcher(description string, fn func(*html.Node) bool) Matcher {
+	return describedMatcher{fn: fn, description: description}
+}
 
 func WithAttrFn(n *html.Node, fn func(string) bool) (string, bool) {
 	for _, a := range n.Attr {
@@ -23,44 +59,100 @@ func WithAttr(n *html.Node, s string) (string, bool) {
 	})
 }
 
+func withAttrValue(attr, value string) Matcher {
+	return NewMatcher(fmt.Sprintf("[%s=%q]", attr, value), func(n *html.Node) bool {
+		v, found := WithAttr(n, attr)
+		return found && v == value
+	})
+}
+
+func withAttrRe(attr string, re *regexp.Regexp) Matcher {
+	return NewMatcher(fmt.Sprintf("[%s=~/%s/]", attr, re), func(n *html.Node) bool {
+		v, found := WithAttr(n, attr)
+		return found && re.MatchString(v)
+	})
+}
+
 func WithID(id string) Matcher {
-	return func(n *html.Node) bool {
-		v, found := WithAttr(n, "id")
-		return found && v == id
-	}
+	return withAttrValue("id", id)
 }
 
 func WithClass(class string) Matcher {
-	return func(n *html.Node) bool {
-		v, found := WithAttr(n, "class")
-		return found && v == class
+	return withAttrValue("class", class)
+}
+
+// classes returns the whitespace separated tokens of the class attribute
+func classes(n *html.Node) []string {
+	v, found := WithAttr(n, "class")
+	if !found {
+		return nil
 	}
+	return strings.Fields(v)
+}
+
+// HasClass matches nodes having class among their class tokens,
+// unlike WithClass the order and presence of other classes is irrelevant
+func HasClass(class string) Matcher {
+	return NewMatcher("."+class, func(n *html.Node) bool {
+		for _, c := range classes(n) {
+			if c == class {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// HasAllClasses matches nodes having every class among their class tokens
+func HasAllClasses(class ...string) Matcher {
+	return NewMatcher("."+strings.Join(class, "."), func(n *html.Node) bool {
+		tokens := classes(n)
+
+		for _, want := range class {
+			found := false
+			for _, c := range tokens {
+				if c == want {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// HasClassPrefix matches nodes with a class token starting with prefix,
+// useful for generated names such as v2__EIReviewDetailsV2__bodyColor
+func HasClassPrefix(prefix string) Matcher {
+	return NewMatcher(fmt.Sprintf("class-prefix(%q)", prefix), func(n *html.Node) bool {
+		for _, c := range classes(n) {
+			if strings.HasPrefix(c, prefix) {
+				return true
+			}
+		}
+		return false
+	})
 }
 
 func WithDataTest(value string) Matcher {
-	return func(n *html.Node) bool {
-		v, found := WithAttr(n, "data-test")
-		return found && v == value
-	}
+	return withAttrValue("data-test", value)
 }
 
 func WithDataTestRe(re *regexp.Regexp) Matcher {
-	return func(n *html.Node) bool {
-		v, found := WithAttr(n, "data-test")
-		return found && re.MatchString(v)
-	}
+	return withAttrRe("data-test", re)
 }
 
 func WithIDRe(re *regexp.Regexp) Matcher {
-	return func(n *html.Node) bool {
-		v, found := WithAttr(n, "id")
-		return found && re.MatchString(v)
-	}
+	return withAttrRe("id", re)
 }
 
 // Find returns first node that matches Matcher
 func Find(node *html.Node, m Matcher) (*html.Node, bool) {
-	if m(node) {
+	if m.Match(node) {
 		return node, true
 	}
 
@@ -75,7 +167,7 @@ func Find(node *html.Node, m Matcher) (*html.Node, bool) {
 func FindAll(node *html.Node, m Matcher) []*html.Node {
 	var result []*html.Node
 
-	if m(node) {
+	if m.Match(node) {
 		result = append(result, node)
 	}
 
@@ -90,10 +182,12 @@ func FindAll(node *html.Node, m Matcher) []*html.Node {
 	return result
 }
 
+var matcherText = NewMatcher("text()", func(n *html.Node) bool {
+	return n.Type == html.TextNode
+})
+
 func ExtractText(node *html.Node) []string {
-	nodes := FindAll(node, func(n *html.Node) bool {
-		return n.Type == html.TextNode
-	})
+	nodes := FindAll(node, matcherText)
 
 	result := make([]string, len(nodes))
 	for i, n := range nodes {
@@ -104,15 +198,10 @@ func ExtractText(node *html.Node) []string {
 }
 
 func AttrValue(node *html.Node, attr string) (string, bool) {
-	var value string
-
-	_, found := Find(node, func(n *html.Node) bool {
-		v, ok := WithAttr(n, attr)
-		if ok {
-			value = v
-		}
-		return ok
-	})
+	n, found := Find(node, HasAttr(attr))
+	if !found {
+		return "", false
+	}
 
-	return value, found
+	return WithAttr(n, attr)
 }
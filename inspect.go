@@ -0,0 +1,55 @@
+package openblind
+
+import (
+	"golang.org/x/net/html"
+)
+
+// MatchCount is the number of nodes a selector matched
+type MatchCount struct {
+	Name     string
+	Selector string
+	Nodes    int
+	// Records is the number of records with at least one match, only set for fields
+	Records int
+}
+
+// Inspection counts the nodes matched by every selector of a section
+type Inspection struct {
+	List   MatchCount
+	Record MatchCount
+	Fields []MatchCount
+}
+
+// Inspect counts the matches of every selector in the document,
+// records are searched inside the first list and fields inside every record
+func (s *Selectors) Inspect(root *html.Node) Inspection {
+	result := Inspection{
+		List:   MatchCount{Name: "list", Selector: s.List.String()},
+		Record: MatchCount{Name: "record", Selector: s.Record.String()},
+	}
+
+	result.List.Nodes = len(FindAll(root, s.List))
+
+	var records []*html.Node
+	if list, found := Find(root, s.List); found {
+		records = FindAll(list, s.Record)
+	}
+	result.Record.Nodes = len(records)
+
+	for _, name := range s.names {
+		m := s.fields[name]
+		count := MatchCount{Name: name, Selector: m.String()}
+
+		for _, record := range records {
+			n := len(FindAll(record, m))
+			count.Nodes += n
+			if n > 0 {
+				count.Records++
+			}
+		}
+
+		result.Fields = append(result.Fields, count)
+	}
+
+	return result
+}
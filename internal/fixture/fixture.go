@@ -0,0 +1,77 @@
+// Package fixture builds the pages and the fake site used by the tests of the crawling packages
+package fixture
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Date is the review date used when the date does not matter
+const Date = "Sun Apr 04 2021"
+
+// Review returns a review list item with the required fields, date as in "Sun Apr 04 2021"
+func Review(id string, date string) string {
+	return fmt.Sprintf(`<li id="empReview_%s">
+	<time class="date subtle small" datetime="%s 12:00:00 GMT+0100 (British Summer Time)"></time>
+	<h2 class="h2 summary strong mb-xsm mt-0">Review %s</h2>
+	<span class="rating"><span title="4.0"></span></span>
+	<span data-test="pros">pros</span>
+	<span data-test="cons">cons</span>
+</li>`, id, date, id)
+}
+
+// Page returns a reviews page holding records
+func Page(records ...string) string {
+	return `<html><body><div id="ReviewsFeed"><ol>` + strings.Join(records, "") + `</ol></div></body></html>`
+}
+
+// Site serves Pages by url recording the urls fetched,
+// it is both a crawler fetcher and an http.RoundTripper
+type Site struct {
+	Pages map[string]string
+
+	mu      sync.Mutex
+	fetched []string
+}
+
+func (s *Site) page(url string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fetched = append(s.fetched, url)
+	page, found := s.Pages[url]
+	return page, found
+}
+
+// Fetch returns the page at url, unknown urls fail
+func (s *Site) Fetch(_ context.Context, url string) (io.ReadCloser, error) {
+	page, found := s.page(url)
+	if !found {
+		return nil, fmt.Errorf("unexpected url: %s", url)
+	}
+
+	return ioutil.NopCloser(strings.NewReader(page)), nil
+}
+
+// RoundTrip responds with the page at the request url, unknown urls are not found
+func (s *Site) RoundTrip(req *http.Request) (*http.Response, error) {
+	page, found := s.page(req.URL.String())
+	if !found {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(page)), Request: req}, nil
+}
+
+// Fetched returns the urls fetched in order
+func (s *Site) Fetched() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.fetched...)
+}
@@ -2,8 +2,11 @@ package interviews
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,13 +16,47 @@ import (
 
 const datetimeFormat = "2006-1-2"
 
+const sectionName = "interviews"
+
+const (
+	fieldDate        = "date"
+	fieldTitle       = "title"
+	fieldApplication = "application"
+	fieldProcess     = "process"
+	fieldQuestions   = "questions"
+	fieldQuestion    = "question"
+	fieldLink        = "link"
+	fieldPermalink   = "permalink"
+	fieldRating      = "rating"
+	fieldColour      = "colour"
+)
+
+// Fields lists the field selectors an interviews profile section must define
+var Fields = []string{
+	fieldDate,
+	fieldTitle,
+	fieldApplication,
+	fieldProcess,
+	fieldQuestions,
+	fieldQuestion,
+	fieldLink,
+	fieldPermalink,
+	fieldRating,
+	fieldColour,
+}
+
+var defaultSelectors = mustSelectors(openblind.DefaultProfile())
+
+// DefaultBaseURL is the site question links are relative to
+const DefaultBaseURL = "https://www.glassdoor.com/"
+
+var defaultBaseURL, _ = url.Parse(DefaultBaseURL)
+
 var (
-	interviewRe        = regexp.MustCompile(`^Interview(?P<ID>\d+)Container$`)
-	matcherContainer   = openblind.WithDataTestRe(interviewRe)
-	matcherTitle       = openblind.WithDataTestRe(regexp.MustCompile(`^Interview\d+Title$`))
-	matcherApplication = openblind.WithDataTestRe(regexp.MustCompile(`^Interview\d+ApplicationDetails$`))
-	matcherProcess     = openblind.WithDataTestRe(regexp.MustCompile(`^Interview\d+Process$`))
-	matcherQuestions   = openblind.WithDataTestRe(regexp.MustCompile(`^Interview\d+Questions$`))
+	questionRe = regexp.MustCompile(`QTN_(?P<ID>\d+)\.htm`)
+	answersRe  = regexp.MustCompile(`^(?P<Count>\d+) Answers?$`)
+
+	errListNotFound = errors.New("failed to find interview list")
 
 	ErrNoDateTime       = errors.New("no date time")
 	ErrParseID          = errors.New("failed to parse id")
@@ -30,149 +67,545 @@ var (
 	ErrParseQuestions   = errors.New("failed to parse questions")
 )
 
+// Offer is the outcome of the interview
+type Offer string
+
+const (
+	OfferUnknown  Offer = ""
+	OfferAccepted Offer = "accepted"
+	OfferDeclined Offer = "declined"
+	OfferNone     Offer = "none"
+)
+
+// Experience is how the candidate felt about the interview
+type Experience string
+
+const (
+	ExperienceUnknown  Experience = ""
+	ExperiencePositive Experience = "positive"
+	ExperienceNeutral  Experience = "neutral"
+	ExperienceNegative Experience = "negative"
+)
+
+// Difficulty is how hard the candidate found the interview
+type Difficulty string
+
+const (
+	DifficultyUnknown   Difficulty = ""
+	DifficultyEasy      Difficulty = "easy"
+	DifficultyAverage   Difficulty = "average"
+	DifficultyDifficult Difficulty = "difficult"
+)
+
+var (
+	offerLabels = map[string]Offer{
+		"Accepted Offer": OfferAccepted,
+		"Declined Offer": OfferDeclined,
+		"No Offer":       OfferNone,
+	}
+	experienceLabels = map[string]Experience{
+		"Positive Experience": ExperiencePositive,
+		"Neutral Experience":  ExperienceNeutral,
+		"Negative Experience": ExperienceNegative,
+	}
+	difficultyLabels = map[string]Difficulty{
+		"Easy Interview":      DifficultyEasy,
+		"Average Interview":   DifficultyAverage,
+		"Difficult Interview": DifficultyDifficult,
+	}
+
+	offerColours = map[string]Offer{
+		"green":  OfferAccepted,
+		"yellow": OfferDeclined,
+		"red":    OfferNone,
+	}
+	experienceColours = map[string]Experience{
+		"green":  ExperiencePositive,
+		"yellow": ExperienceNeutral,
+		"red":    ExperienceNegative,
+	}
+	difficultyColours = map[string]Difficulty{
+		"green":  DifficultyEasy,
+		"yellow": DifficultyAverage,
+		"red":    DifficultyDifficult,
+	}
+)
+
+// Question asked during the interview
+type Question struct {
+	ID      string `json:"id,omitempty"`
+	Text    string `json:"text,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Answers int    `json:"answers,omitempty"`
+}
+
 type Interview struct {
-	ID          string    `json:"id,omitempty"`
-	Date        time.Time `json:"date,omitempty"`
-	Title       string    `json:"title,omitempty"`
-	Application []string  `json:"application,omitempty"`
-	Process     []string  `json:"process,omitempty"`
-	Questions   []string  `json:"questions,omitempty"`
-}
-
-func parseID(node *html.Node) (string, error) {
-	var value string
-
-	_, found := openblind.Find(node, func(n *html.Node) bool {
-		v, ok := openblind.WithAttr(n, "data-test")
-		if ok && interviewRe.MatchString(v) {
-			idx := interviewRe.SubexpIndex("ID")
-			value = interviewRe.FindStringSubmatch(v)[idx]
-		}
+	ID          string     `json:"id,omitempty"`
+	Date        time.Time  `json:"date,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Application []string   `json:"application,omitempty"`
+	Process     []string   `json:"process,omitempty"`
+	Questions   []Question `json:"questions,omitempty"`
+
+	Offer      Offer      `json:"offer,omitempty"`
+	Experience Experience `json:"experience,omitempty"`
+	Difficulty Difficulty `json:"difficulty,omitempty"`
+}
 
-		return ok
-	})
+// NewSelectors compiles the interviews section of the profile
+func NewSelectors(p *openblind.Profile) (*openblind.Selectors, error) {
+	section, err := p.Section(sectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	return section.Compile(Fields...)
+}
+
+func mustSelectors(p *openblind.Profile) *openblind.Selectors {
+	s, err := NewSelectors(p)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// DefaultSelectors returns the interviews selectors of the default profile
+func DefaultSelectors() *openblind.Selectors {
+	return defaultSelectors
+}
+
+// parser reads interviews with the selectors of a profile
+type parser struct {
+	*openblind.Selectors
+
+	// base resolves relative links of interviews without a permalink
+	base *url.URL
+}
+
+func newError(record, node *html.Node, field string, m openblind.Matcher, err error) error {
+	return openblind.NewParseError(sectionName, record, node, field, m, err)
+}
+
+func (p parser) parseID(node *html.Node) (string, error) {
+	container, found := openblind.Find(node, p.Record)
 	if !found {
-		return "", ErrParseID
+		return "", newError(node, node, "id", p.Record, ErrParseID)
 	}
 
-	return value, nil
+	id, _ := p.RecordID(container)
+
+	return id, nil
 }
 
 // <time dateTime="2021-3-25">25 Mar 2021</time>
-func parseDateTime(node *html.Node) (time.Time, error) {
-	value, found := openblind.AttrValue(node, "datetime")
+func (p parser) parseDateTime(node *html.Node) (time.Time, error) {
+	m := p.Field(fieldDate)
+
+	dateNode, found := openblind.Find(node, m)
 	if !found {
-		return time.Time{}, ErrParseDate
+		return time.Time{}, newError(node, node, "date", m, ErrParseDate)
 	}
 
-	return time.Parse(datetimeFormat, value)
+	value, _ := openblind.WithAttr(dateNode, "datetime")
+
+	parsed, err := time.Parse(datetimeFormat, value)
+	if err != nil {
+		return time.Time{}, newError(node, dateNode, "date", m, fmt.Errorf("%s: %w", err.Error(), ErrParseDate))
+	}
+
+	return parsed, nil
 }
 
-func parseTitle(node *html.Node) ([]string, error) {
-	titleNode, found := openblind.Find(node, matcherTitle)
+func (p parser) parseTitle(node *html.Node) ([]string, error) {
+	titleNode, found := openblind.Find(node, p.Field(fieldTitle))
 	if !found {
-		return nil, ErrParseTitle
+		return nil, newError(node, node, "title", p.Field(fieldTitle), ErrParseTitle)
 	}
 
 	return openblind.ExtractText(titleNode), nil
 }
 
-func parseApplication(node *html.Node) ([]string, error) {
-	applicationNode, found := openblind.Find(node, matcherApplication)
+func (p parser) parseApplication(node *html.Node) ([]string, error) {
+	applicationNode, found := openblind.Find(node, p.Field(fieldApplication))
 	if !found {
-		return nil, ErrParseApplication
+		return nil, newError(node, node, "application", p.Field(fieldApplication), ErrParseApplication)
 	}
 
 	return openblind.ExtractText(applicationNode), nil
 }
 
-func parseProcess(node *html.Node) ([]string, error) {
-	processNode, found := openblind.Find(node, matcherProcess)
+func (p parser) parseProcess(node *html.Node) ([]string, error) {
+	processNode, found := openblind.Find(node, p.Field(fieldProcess))
 	if !found {
-		return nil, ErrParseProcess
+		return nil, newError(node, node, "process", p.Field(fieldProcess), ErrParseProcess)
 	}
 
 	return openblind.ExtractText(processNode), nil
 }
 
-func parseQuestions(node *html.Node) ([]string, error) {
-	questionsNode, found := openblind.Find(node, matcherQuestions)
+// parsePermalink returns the interview absolute url, used to resolve relative links
+func (p parser) parsePermalink(node *html.Node) *url.URL {
+	permalink, found := openblind.Find(node, p.Field(fieldPermalink))
 	if !found {
-		return nil, ErrParseQuestions
+		return nil
+	}
+
+	href, _ := openblind.WithAttr(permalink, "href")
+
+	u, err := url.Parse(href)
+	if err != nil || !u.IsAbs() {
+		return nil
 	}
 
-	return openblind.ExtractText(questionsNode), nil
+	return u
 }
 
-func parseInterview(node *html.Node) (Interview, error) {
-	var result Interview
+// ResolveURL returns href resolved against base, DefaultBaseURL when base is nil,
+// and an empty string when href is empty or not a valid url
+func ResolveURL(base *url.URL, href string) string {
+	if href == "" {
+		return ""
+	}
 
-	id, err := parseID(node)
+	u, err := url.Parse(href)
 	if err != nil {
-		return result, err
+		return ""
 	}
 
-	datetime, err := parseDateTime(node)
-	if err != nil {
-		return result, ErrNoDateTime
+	if base == nil {
+		base = defaultBaseURL
 	}
 
-	title, err := parseTitle(node)
-	if err != nil {
-		return result, err
+	return base.ResolveReference(u).String()
+}
+
+// parseQuestion reads the question text and its link, the link text holds the answers count
+// <a href="/Interview/Why-do-you-want-to-work-for-Tesla-QTN_4358096.htm">Answer Question</a>
+func (p parser) parseQuestion(node *html.Node, base *url.URL) Question {
+	var result Question
+
+	text := openblind.FlattenByNewLine(openblind.ExtractText(node))
+
+	link, found := openblind.Find(node, p.Field(fieldLink))
+	if found {
+		linkText := openblind.RemoveStrings()(openblind.ExtractText(link))
+		text = openblind.RemoveStrings(linkText...)(text)
+
+		if matches := answersRe.FindStringSubmatch(strings.Join(linkText, " ")); matches != nil {
+			result.Answers, _ = strconv.Atoi(matches[answersRe.SubexpIndex("Count")])
+		}
+
+		href, _ := openblind.WithAttr(link, "href")
+		if matches := questionRe.FindStringSubmatch(href); matches != nil {
+			result.ID = matches[questionRe.SubexpIndex("ID")]
+		}
+
+		result.URL = ResolveURL(base, href)
 	}
 
-	application, err := parseApplication(node)
-	if err != nil {
-		return result, err
+	result.Text = strings.Join(openblind.RemoveStrings()(text), " ")
+
+	return result
+}
+
+func (p parser) parseQuestions(node *html.Node) ([]Question, error) {
+	questionsNode, found := openblind.Find(node, p.Field(fieldQuestions))
+	if !found {
+		return nil, newError(node, node, "questions", p.Field(fieldQuestions), ErrParseQuestions)
 	}
 
-	process, err := parseProcess(node)
-	if err != nil {
-		return result, err
+	base := p.parsePermalink(node)
+	if base == nil {
+		base = p.base
+	}
+
+	items := openblind.FindAll(questionsNode, p.Field(fieldQuestion))
+
+	result := make([]Question, 0, len(items))
+	for _, item := range items {
+		question := p.parseQuestion(item, base)
+		if question.Text == "" {
+			continue
+		}
+
+		result = append(result, question)
 	}
 
-	questions, err := parseQuestions(node)
+	return result, nil
+}
+
+// ratingColour returns the colour class of the rating indicator
+// <span class="d-inline-block mr-xxsm green css-ozq8ud"></span>
+func (p parser) ratingColour(node *html.Node) string {
+	indicator, found := openblind.Find(node, p.Field(fieldColour))
+	if !found {
+		return ""
+	}
+
+	class, _ := openblind.WithAttr(indicator, "class")
+	for _, token := range strings.Fields(class) {
+		switch token {
+		case "green", "yellow", "red":
+			return token
+		}
+	}
+
+	return ""
+}
+
+// parseRatings reads the offer, experience and difficulty blocks.
+// Blocks are identified by their text and come in any order. When the text is
+// not recognised (e.g. a localised page) and all three blocks are present,
+// the value is derived from the colour of the block in that position,
+// otherwise it is left unknown.
+func (p parser) parseRatings(node *html.Node) (Offer, Experience, Difficulty) {
+	var (
+		offer      Offer
+		experience Experience
+		difficulty Difficulty
+	)
+
+	blocks := openblind.FindAll(node, p.Field(fieldRating))
+	for i, block := range blocks {
+		text := strings.Join(openblind.RemoveStrings()(openblind.ExtractText(block)), " ")
+
+		if v, ok := offerLabels[text]; ok {
+			offer = v
+			continue
+		}
+		if v, ok := experienceLabels[text]; ok {
+			experience = v
+			continue
+		}
+		if v, ok := difficultyLabels[text]; ok {
+			difficulty = v
+			continue
+		}
+
+		// the position only tells the block apart on a full row
+		if len(blocks) != 3 {
+			continue
+		}
+
+		colour := p.ratingColour(block)
+		switch {
+		case i == 0 && offer == OfferUnknown:
+			offer = offerColours[colour]
+		case i == 1 && experience == ExperienceUnknown:
+			experience = experienceColours[colour]
+		case i == 2 && difficulty == DifficultyUnknown:
+			difficulty = difficultyColours[colour]
+		}
+	}
+
+	return offer, experience, difficulty
+}
+
+// field parses a single interview field into r, optional fields never fail
+// while a required field failing skips the record in lenient mode
+type field struct {
+	name     string
+	required bool
+	parse    func(p parser, node *html.Node, r *Interview) error
+}
+
+var fields = []field{
+	{name: "date", required: true, parse: func(p parser, node *html.Node, r *Interview) error {
+		datetime, err := p.parseDateTime(node)
+		if err != nil {
+			// featured interviews don't have a datetime
+			return newError(node, node, "date", p.Field(fieldDate), ErrNoDateTime)
+		}
+		r.Date = datetime
+		return nil
+	}},
+	{name: "title", required: true, parse: func(p parser, node *html.Node, r *Interview) error {
+		title, err := p.parseTitle(node)
+		r.Title = strings.Join(title, ",")
+		return err
+	}},
+	{name: "application", required: true, parse: func(p parser, node *html.Node, r *Interview) error {
+		application, err := p.parseApplication(node)
+		r.Application = openblind.RemoveStrings("Application")(openblind.FlattenByNewLine(application))
+		return err
+	}},
+	{name: "process", required: true, parse: func(p parser, node *html.Node, r *Interview) error {
+		process, err := p.parseProcess(node)
+		r.Process = openblind.FlattenByNewLine(process)
+		return err
+	}},
+	{name: "questions", required: true, parse: func(p parser, node *html.Node, r *Interview) (err error) {
+		r.Questions, err = p.parseQuestions(node)
+		return err
+	}},
+	{name: "ratings", parse: func(p parser, node *html.Node, r *Interview) error {
+		r.Offer, r.Experience, r.Difficulty = p.parseRatings(node)
+		return nil
+	}},
+}
+
+// Options controls how malformed interviews are handled
+type Options struct {
+	// Lenient skips interviews failing a required field instead of stopping the parse
+	Lenient bool
+	// Diagnostic receives the skipped interviews
+	Diagnostic openblind.Diagnostic
+	// Selectors locate the interviews, the default profile is used when nil
+	Selectors *openblind.Selectors
+	// BaseURL, usually the page url, resolves question links of interviews
+	// without a permalink, DefaultBaseURL is used when nil
+	BaseURL *url.URL
+}
+
+func (o Options) parser() parser {
+	result := parser{Selectors: o.Selectors, base: o.BaseURL}
+	if result.Selectors == nil {
+		result.Selectors = defaultSelectors
+	}
+	if result.base == nil {
+		result.base = defaultBaseURL
+	}
+	return result
+}
+
+func (o Options) report(err error) {
+	if o.Diagnostic != nil {
+		o.Diagnostic(err)
+	}
+}
+
+func parseInterview(node *html.Node) (Interview, error) {
+	return parseInterviewOptions(node, Options{})
+}
+
+func parseInterviewOptions(node *html.Node, opts Options) (Interview, error) {
+	p := opts.parser()
+
+	id, err := p.parseID(node)
 	if err != nil {
-		return result, err
+		return Interview{}, err
 	}
 
-	return Interview{
-		ID:          id,
-		Date:        datetime,
-		Title:       strings.Join(title, ","),
-		Application: openblind.RemoveStrings("Application")(openblind.FlattenByNewLine(application)),
-		Process:     openblind.FlattenByNewLine(process),
-		Questions:   openblind.RemoveStrings("Answer Question", "1 Answer")(openblind.FlattenByNewLine(questions)),
-	}, nil
+	result := Interview{ID: id}
+	for _, f := range fields {
+		if err := f.parse(p, node, &result); err != nil && f.required {
+			return Interview{}, openblind.WithRecordID(err, id)
+		}
+	}
+
+	return result, nil
 }
 
-func Parse(r io.Reader) ([]Interview, error) {
+// recordHandler parses each record node calling fn with the result,
+// in lenient mode failing records are reported and skipped
+func recordHandler(opts Options, fn func(Interview) error) func(*html.Node) error {
+	return func(node *html.Node) error {
+		res, err := parseInterviewOptions(node, opts)
+		switch {
+		// featured interviews don't have a datetime, ignore
+		case errors.Is(err, ErrNoDateTime):
+			return nil
+		case err != nil && opts.Lenient:
+			opts.report(err)
+			return nil
+		case err != nil:
+			return err
+		}
+
+		return fn(res)
+	}
+}
+
+// ParseFunc calls fn for each interview as soon as it is parsed,
+// parsing stops at the first error returned by fn
+func ParseFunc(r io.Reader, fn func(Interview) error) error {
+	return ParseFuncOptions(r, Options{}, fn)
+}
+
+// ParseFuncOptions is like ParseFunc with malformed interviews handled according to opts
+func ParseFuncOptions(r io.Reader, opts Options, fn func(Interview) error) error {
 	root, err := html.Parse(r)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	list, ok := openblind.Find(root, openblind.WithDataTest("InterviewList"))
+	return ParseNode(root, opts, fn)
+}
+
+// ParseNode is like ParseFuncOptions for an already parsed document
+func ParseNode(root *html.Node, opts Options, fn func(Interview) error) error {
+	p := opts.parser()
+
+	list, ok := openblind.Find(root, p.List)
 	if !ok {
-		return nil, errors.New("failed to find interview list")
+		return openblind.NewParseError(sectionName, nil, nil, "list", p.List, errListNotFound)
 	}
 
-	interviews := openblind.FindAll(list, matcherContainer)
-
-	result := make([]Interview, 0, len(interviews))
-	for _, interview := range interviews {
-		res, err := parseInterview(interview)
-		if err != nil {
-			// featured interviews don't have a datetime, ignore
-			if errors.Is(err, ErrNoDateTime) {
-				continue
-			}
-			return nil, err
+	handle := recordHandler(opts, fn)
+	for _, interview := range openblind.FindAll(list, p.Record) {
+		if err := handle(interview); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
-		result = append(result, res)
+// ParseStream is like ParseFunc but tokenizes the document in a single pass
+// without building the whole DOM, only the current interview is kept in memory
+func ParseStream(r io.Reader, fn func(Interview) error) error {
+	return ParseStreamOptions(r, Options{}, fn)
+}
+
+// ParseStreamOptions is like ParseStream with malformed interviews handled according to opts
+func ParseStreamOptions(r io.Reader, opts Options, fn func(Interview) error) error {
+	p := opts.parser()
+
+	err := openblind.StreamNodes(r, p.List, p.Record, recordHandler(opts, fn))
+	if errors.Is(err, openblind.ErrListNotFound) {
+		return openblind.NewParseError(sectionName, nil, nil, "list", p.List, errListNotFound)
+	}
+
+	return err
+}
+
+func Parse(r io.Reader) ([]Interview, error) {
+	result := make([]Interview, 0)
+
+	err := ParseFunc(r, func(interview Interview) error {
+		result = append(result, interview)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
+
+// ParseLenient parses every well formed interview skipping the malformed ones,
+// the errors of skipped interviews are returned as diagnostics
+func ParseLenient(r io.Reader) ([]Interview, []error, error) {
+	var (
+		result      = make([]Interview, 0)
+		diagnostics []error
+	)
+
+	opts := Options{
+		Lenient: true,
+		Diagnostic: func(err error) {
+			diagnostics = append(diagnostics, err)
+		},
+	}
+
+	err := ParseFuncOptions(r, opts, func(interview Interview) error {
+		result = append(result, interview)
+		return nil
+	})
+	if err != nil {
+		return nil, diagnostics, err
+	}
+
+	return result, diagnostics, nil
+}
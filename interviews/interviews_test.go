@@ -1,11 +1,16 @@
 package interviews
 
 import (
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/jacoelho/openblind"
 	"golang.org/x/net/html"
 )
 
@@ -82,7 +87,16 @@ func TestParseInterview(t *testing.T) {
 		Title:       "Mechanical Engineer Intern Interview",
 		Application: []string{"I interviewed at Tesla"},
 		Process:     []string{"Highly flexible depending on team and directly interviewed by the team member, so could be just book technician questions or design scenarios. The number of times you get interviewed is also dependent on the team."},
-		Questions:   []string{"Why do you want to work for Tesla?"},
+		Questions: []Question{
+			{
+				ID:   "4358096",
+				Text: "Why do you want to work for Tesla?",
+				URL:  "http://www.glassdoor.co.uk/Interview/Why-do-you-want-to-work-for-Tesla-QTN_4358096.htm",
+			},
+		},
+		Offer:      OfferAccepted,
+		Experience: ExperiencePositive,
+		Difficulty: DifficultyAverage,
 	}
 
 	root, err := html.Parse(strings.NewReader(fixture))
@@ -102,6 +116,258 @@ func TestParseInterview(t *testing.T) {
 
 }
 
+func TestParseInterviewError(t *testing.T) {
+	broken := strings.Replace(fixture, `data-test="Interview44944117Process"`, `data-test="Interview44944117Removed"`, 1)
+
+	root, err := html.Parse(strings.NewReader(broken))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	record, _ := openblind.Find(root, DefaultSelectors().Record)
+
+	_, err = parseInterview(record)
+	if !errors.Is(err, ErrParseProcess) {
+		t.Fatalf("parseInterview() error = %v, want %v", err, ErrParseProcess)
+	}
+
+	var parseErr *openblind.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("parseInterview() error = %T, want *openblind.ParseError", err)
+	}
+
+	if parseErr.RecordID != "44944117" || parseErr.Field != "process" || parseErr.Section != "interviews" {
+		t.Errorf("parseInterview() error = %+v", parseErr)
+	}
+}
+
+func TestParseRatings(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		wantOffer      Offer
+		wantExperience Experience
+		wantDifficulty Difficulty
+	}{
+		{
+			name: "english labels",
+			input: `<div class="row">
+	<div data-test="Interview1Rating"><span class="d-inline-block mr-xxsm red css-ozq8ud"></span>No Offer</div>
+	<div data-test="Interview1Rating"><span class="d-inline-block mr-xxsm red css-ozq8ud"></span>Negative Experience</div>
+	<div data-test="Interview1Rating"><span class="d-inline-block mr-xxsm red css-ozq8ud"></span>Difficult Interview</div>
+</div>`,
+			wantOffer:      OfferNone,
+			wantExperience: ExperienceNegative,
+			wantDifficulty: DifficultyDifficult,
+		},
+		{
+			name: "labels out of order",
+			input: `<div class="row">
+	<div data-test="Interview1Rating"><span class="d-inline-block mr-xxsm green css-ozq8ud"></span>Easy Interview</div>
+	<div data-test="Interview1Rating"><span class="d-inline-block mr-xxsm yellow css-ozq8ud"></span>Declined Offer</div>
+</div>`,
+			wantOffer:      OfferDeclined,
+			wantDifficulty: DifficultyEasy,
+		},
+		{
+			name: "localised labels",
+			input: `<div class="row">
+	<div data-test="Interview1Rating"><span class="d-inline-block mr-xxsm green css-ozq8ud"></span>Angebot angenommen</div>
+	<div data-test="Interview1Rating"><span class="d-inline-block mr-xxsm yellow css-ozq8ud"></span>Neutrale Erfahrung</div>
+	<div data-test="Interview1Rating"><span class="d-inline-block mr-xxsm red css-ozq8ud"></span>Schwieriges Vorstellungsgespräch</div>
+</div>`,
+			wantOffer:      OfferAccepted,
+			wantExperience: ExperienceNeutral,
+			wantDifficulty: DifficultyDifficult,
+		},
+		{
+			name: "localised labels missing a block",
+			input: `<div class="row">
+	<div data-test="Interview1Rating"><span class="d-inline-block mr-xxsm yellow css-ozq8ud"></span>Neutrale Erfahrung</div>
+	<div data-test="Interview1Rating"><span class="d-inline-block mr-xxsm red css-ozq8ud"></span>Schwieriges Vorstellungsgespräch</div>
+</div>`,
+		},
+		{
+			name:  "no ratings",
+			input: `<div class="row"></div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			offer, experience, difficulty := parser{Selectors: DefaultSelectors(), base: defaultBaseURL}.parseRatings(root)
+			if offer != tt.wantOffer {
+				t.Errorf("parseRatings() offer = %q, want %q", offer, tt.wantOffer)
+			}
+			if experience != tt.wantExperience {
+				t.Errorf("parseRatings() experience = %q, want %q", experience, tt.wantExperience)
+			}
+			if difficulty != tt.wantDifficulty {
+				t.Errorf("parseRatings() difficulty = %q, want %q", difficulty, tt.wantDifficulty)
+			}
+		})
+	}
+}
+
+func TestParseQuestions(t *testing.T) {
+	input := `<div data-test="Interview1Container">
+	<ul data-test="Interview1Questions">
+		<li class="mb-std">
+			<span class="d-inline-block mb-sm">Describe a project
+you are proud of.</span>
+			<div><a href="/Interview/Describe-a-project-QTN_1.htm">3 Answers</a></div>
+		</li>
+		<li class="mb-std">
+			<span class="d-inline-block mb-sm">Why Tesla?</span>
+			<div><a href="/Interview/Why-Tesla-QTN_2.htm">1 Answer</a></div>
+		</li>
+		<li class="mb-std">
+			<span class="d-inline-block mb-sm">Question without link</span>
+		</li>
+	</ul>
+</div>`
+
+	pageURL, err := url.Parse("https://www.glassdoor.co.uk/Interview/Tesla-Interview-Questions-E43129_P2.htm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		host string
+	}{
+		{name: "default base", opts: Options{}, host: "https://www.glassdoor.com"},
+		{name: "page url base", opts: Options{BaseURL: pageURL}, host: "https://www.glassdoor.co.uk"},
+	}
+
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := []Question{
+				{ID: "1", Text: "Describe a project you are proud of.", URL: tt.host + "/Interview/Describe-a-project-QTN_1.htm", Answers: 3},
+				{ID: "2", Text: "Why Tesla?", URL: tt.host + "/Interview/Why-Tesla-QTN_2.htm", Answers: 1},
+				{Text: "Question without link"},
+			}
+
+			got, err := tt.opts.parser().parseQuestions(root)
+			if err != nil {
+				t.Fatalf("parseQuestions() error = %v", err)
+			}
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("parseQuestions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// fixturePage returns a page with n copies of the fixture, each with its own id
+func fixturePage(n int) string {
+	var sb strings.Builder
+
+	sb.WriteString(`<html><body><div data-test="InterviewList">`)
+	for i := 0; i < n; i++ {
+		sb.WriteString(strings.ReplaceAll(fixture, "44944117", strconv.Itoa(44944117+i)))
+	}
+	sb.WriteString(`</div></body></html>`)
+
+	return sb.String()
+}
+
+func TestParseStream(t *testing.T) {
+	page := fixturePage(3)
+
+	want, err := Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(want) != 3 {
+		t.Fatalf("Parse() got %d records, want 3", len(want))
+	}
+
+	var got []Interview
+	err = ParseStream(strings.NewReader(page), func(v Interview) error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseStream() error = %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseStream() mismatch (-Parse +ParseStream):\n%s", diff)
+	}
+}
+
+func TestParseStreamListNotFound(t *testing.T) {
+	err := ParseStream(strings.NewReader(`<html><body></body></html>`), func(Interview) error {
+		return nil
+	})
+	if !errors.Is(err, errListNotFound) {
+		t.Errorf("ParseStream() error = %v, want %v", err, errListNotFound)
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	// the second record is missing a required field
+	page := strings.Replace(fixturePage(3), `data-test="Interview44944118Process"`, `data-test="Interview44944118Removed"`, 1)
+
+	if _, err := Parse(strings.NewReader(page)); !errors.Is(err, ErrParseProcess) {
+		t.Fatalf("Parse() error = %v, want %v", err, ErrParseProcess)
+	}
+
+	got, diagnostics, err := ParseLenient(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("ParseLenient() error = %v", err)
+	}
+
+	var ids []string
+	for _, v := range got {
+		ids = append(ids, v.ID)
+	}
+
+	if diff := cmp.Diff([]string{"44944117", "44944119"}, ids); diff != "" {
+		t.Errorf("ParseLenient() ids mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("ParseLenient() got %d diagnostics, want 1", len(diagnostics))
+	}
+
+	var parseErr *openblind.ParseError
+	if !errors.As(diagnostics[0], &parseErr) || !errors.Is(parseErr, ErrParseProcess) {
+		t.Fatalf("ParseLenient() diagnostic = %v, want %v", diagnostics[0], ErrParseProcess)
+	}
+
+	if parseErr.RecordID != "44944118" || parseErr.Field != "process" {
+		t.Errorf("ParseLenient() diagnostic = %+v", parseErr)
+	}
+
+	var streamed []Interview
+	err = ParseStreamOptions(strings.NewReader(page), Options{Lenient: true}, func(v Interview) error {
+		streamed = append(streamed, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseStreamOptions() error = %v", err)
+	}
+
+	if diff := cmp.Diff(got, streamed); diff != "" {
+		t.Errorf("ParseStreamOptions() mismatch (-ParseLenient +ParseStreamOptions):\n%s", diff)
+	}
+}
+
 func mustParseTime(t *testing.T, s string) time.Time {
 	t.Helper()
 
@@ -112,3 +378,33 @@ func mustParseTime(t *testing.T, s string) time.Time {
 
 	return parsed
 }
+
+// BenchmarkParse compares building the whole DOM with streaming the records
+func BenchmarkParse(b *testing.B) {
+	page := fixturePage(50)
+
+	parsers := []struct {
+		name  string
+		parse func(r io.Reader) error
+	}{
+		{name: "dom", parse: func(r io.Reader) error {
+			_, err := Parse(r)
+			return err
+		}},
+		{name: "stream", parse: func(r io.Reader) error {
+			return ParseStream(r, func(Interview) error { return nil })
+		}},
+	}
+
+	for _, p := range parsers {
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if err := p.parse(strings.NewReader(page)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package openblind
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+func describeAll(matchers []Matcher) string {
+	descriptions := make([]string, len(matchers))
+	for i, m := range matchers {
+		descriptions[i] = m.String()
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// And matches nodes matching every matcher
+func And(matchers ...Matcher) Matcher {
+	return NewMatcher("and("+describeAll(matchers)+")", func(n *html.Node) bool {
+		for _, m := range matchers {
+			if !m.Match(n) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Or matches nodes matching any of the matchers
+func Or(matchers ...Matcher) Matcher {
+	return NewMatcher("or("+describeAll(matchers)+")", func(n *html.Node) bool {
+		for _, m := range matchers {
+			if m.Match(n) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not matches nodes not matching m
+func Not(m Matcher) Matcher {
+	return NewMatcher("not("+m.String()+")", func(n *html.Node) bool {
+		return !m.Match(n)
+	})
+}
+
+// WithTag matches elements by tag name, e.g. li
+func WithTag(tag string) Matcher {
+	return NewMatcher(tag, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == tag
+	})
+}
+
+// HasAttr matches nodes with the attribute regardless of its value
+func HasAttr(attr string) Matcher {
+	return NewMatcher("["+attr+"]", func(n *html.Node) bool {
+		_, found := WithAttr(n, attr)
+		return found
+	})
+}
+
+// HasChild matches nodes with a direct child matching m
+func HasChild(m Matcher) Matcher {
+	return NewMatcher("has-child("+m.String()+")", func(n *html.Node) bool {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if m.Match(c) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// HasDescendant matches nodes with any descendant matching m
+func HasDescendant(m Matcher) Matcher {
+	return NewMatcher("has-descendant("+m.String()+")", func(n *html.Node) bool {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if _, found := Find(c, m); found {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// HasAncestor matches nodes with any ancestor matching m
+func HasAncestor(m Matcher) Matcher {
+	return NewMatcher("has-ancestor("+m.String()+")", func(n *html.Node) bool {
+		for p := n.Parent; p != nil; p = p.Parent {
+			if m.Match(p) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// TextContains matches elements whose text contains s
+func TextContains(s string) Matcher {
+	return NewMatcher(fmt.Sprintf("text-contains(%q)", s), func(n *html.Node) bool {
+		return n.Type == html.ElementNode && strings.Contains(strings.Join(ExtractText(n), ""), s)
+	})
+}
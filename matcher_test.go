@@ -0,0 +1,134 @@
+package openblind
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestMatchers(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(cssFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		matcher         Matcher
+		want            []string
+		wantDescription string
+	}{
+		{
+			name:            "with tag",
+			matcher:         WithTag("h2"),
+			want:            []string{"t1", "t2", "t3"},
+			wantDescription: "h2",
+		},
+		{
+			name:            "has attr",
+			matcher:         HasAttr("lang"),
+			want:            []string{"r1", "r2", "r3"},
+			wantDescription: "[lang]",
+		},
+		{
+			name:            "and",
+			matcher:         And(WithTag("span"), WithDataTest("pros")),
+			want:            []string{"p1", "p2"},
+			wantDescription: `and(span, [data-test="pros"])`,
+		},
+		{
+			name:            "or",
+			matcher:         Or(WithID("t1"), WithID("footer")),
+			want:            []string{"t1", "footer"},
+			wantDescription: `or([id="t1"], [id="footer"])`,
+		},
+		{
+			name:            "not",
+			matcher:         And(WithTag("li"), Not(HasAttr("lang"))),
+			want:            []string{"r4"},
+			wantDescription: "and(li, not([lang]))",
+		},
+		{
+			name:            "has child",
+			matcher:         HasChild(WithDataTest("cons")),
+			want:            []string{"r1"},
+			wantDescription: `has-child([data-test="cons"])`,
+		},
+		{
+			name:            "has descendant",
+			matcher:         And(WithTag("li"), HasDescendant(WithTag("span"))),
+			want:            []string{"r1", "r2", "r4"},
+			wantDescription: "and(li, has-descendant(span))",
+		},
+		{
+			name:            "has ancestor",
+			matcher:         And(WithTag("span"), HasAncestor(WithTag("p"))),
+			want:            []string{"s4"},
+			wantDescription: "and(span, has-ancestor(p))",
+		},
+		{
+			name:            "text contains",
+			matcher:         And(WithTag("li"), TextContains("Good")),
+			want:            []string{"r2"},
+			wantDescription: `and(li, text-contains("Good"))`,
+		},
+		{
+			name:            "regexp",
+			matcher:         WithDataTestRe(regexp.MustCompile(`^Review[13]Container$`)),
+			want:            []string{"r1", "r3"},
+			wantDescription: `[data-test=~/^Review[13]Container$/]`,
+		},
+		{
+			name:            "has class",
+			matcher:         HasClass("summary"),
+			want:            []string{"t1", "t2"},
+			wantDescription: ".summary",
+		},
+		{
+			name:            "has class ignores partial tokens",
+			matcher:         HasClass("summ"),
+			want:            nil,
+			wantDescription: ".summ",
+		},
+		{
+			name:            "has all classes in any order",
+			matcher:         HasAllClasses("strong", "h2"),
+			want:            []string{"t1"},
+			wantDescription: ".strong.h2",
+		},
+		{
+			name:            "has class prefix",
+			matcher:         HasClassPrefix("v2__EIReviewDetailsV2__"),
+			want:            []string{"footer"},
+			wantDescription: `class-prefix("v2__EIReviewDetailsV2__")`,
+		},
+		{
+			name:            "with class is exact",
+			matcher:         WithClass("summary h2"),
+			want:            nil,
+			wantDescription: `[class="summary h2"]`,
+		},
+		{
+			name:            "selector",
+			matcher:         MustCompile("li > h2.summary"),
+			want:            []string{"t1", "t2"},
+			wantDescription: "li > h2.summary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectIDs(t, root, tt.matcher)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("FindAll() mismatch (-want +got):\n%s", diff)
+			}
+
+			if description := tt.matcher.String(); description != tt.wantDescription {
+				t.Errorf("String() = %q, want %q", description, tt.wantDescription)
+			}
+		})
+	}
+}
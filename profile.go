@@ -0,0 +1,184 @@
+package openblind
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"golang.org/x/net/html"
+)
+
+// ProfileVersion is the selector profile format version supported
+const ProfileVersion = 1
+
+//go:embed profiles/default.json
+var defaultProfile []byte
+
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// Profile describes, per section, how to locate the list, its records and their fields
+type Profile struct {
+	Version  int                       `json:"version"`
+	Sections map[string]SectionProfile `json:"sections"`
+}
+
+// SectionProfile holds the css selectors of a section
+type SectionProfile struct {
+	List   string            `json:"list"`
+	Record string            `json:"record"`
+	ID     IDProfile         `json:"id"`
+	Fields map[string]string `json:"fields"`
+}
+
+// IDProfile extracts the record id from an attribute, the first pattern group is the id
+type IDProfile struct {
+	Attr    string `json:"attr"`
+	Pattern string `json:"pattern"`
+}
+
+// Selectors are the compiled matchers of a section profile
+type Selectors struct {
+	List   Matcher
+	Record Matcher
+
+	idAttr    string
+	idPattern *regexp.Regexp
+	names     []string
+	fields    map[string]Matcher
+}
+
+// RecordID returns the id of the record node
+func (s *Selectors) RecordID(record *html.Node) (string, bool) {
+	v, found := WithAttr(record, s.idAttr)
+	if !found {
+		return "", false
+	}
+
+	matches := s.idPattern.FindStringSubmatch(v)
+	if matches == nil {
+		return "", false
+	}
+
+	return matches[1], true
+}
+
+// Field returns the matcher of the named field, the field must have been required when compiling
+func (s *Selectors) Field(name string) Matcher {
+	m, found := s.fields[name]
+	if !found {
+		panic(fmt.Sprintf("openblind: unknown field %q", name))
+	}
+	return m
+}
+
+// Fields returns the names of the compiled fields in the order they were required
+func (s *Selectors) Fields() []string {
+	return s.names
+}
+
+// Compile compiles the section selectors, every field in fields must be defined
+func (p SectionProfile) Compile(fields ...string) (*Selectors, error) {
+	list, err := Compile(p.List)
+	if err != nil {
+		return nil, fmt.Errorf("list: %s: %w", err.Error(), ErrInvalidProfile)
+	}
+
+	record, err := Compile(p.Record)
+	if err != nil {
+		return nil, fmt.Errorf("record: %s: %w", err.Error(), ErrInvalidProfile)
+	}
+
+	if p.ID.Attr == "" {
+		return nil, fmt.Errorf("id: missing attr: %w", ErrInvalidProfile)
+	}
+
+	idPattern, err := regexp.Compile(p.ID.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("id: %s: %w", err.Error(), ErrInvalidProfile)
+	}
+
+	if idPattern.NumSubexp() < 1 {
+		return nil, fmt.Errorf("id: pattern %q has no group: %w", p.ID.Pattern, ErrInvalidProfile)
+	}
+
+	result := &Selectors{
+		List:      list,
+		Record:    And(record, withAttrRe(p.ID.Attr, idPattern)),
+		idAttr:    p.ID.Attr,
+		idPattern: idPattern,
+		fields:    make(map[string]Matcher, len(fields)),
+	}
+
+	for _, name := range fields {
+		selector, found := p.Fields[name]
+		if !found {
+			return nil, fmt.Errorf("field %s: missing selector: %w", name, ErrInvalidProfile)
+		}
+
+		m, err := Compile(selector)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %s: %w", name, err.Error(), ErrInvalidProfile)
+		}
+
+		result.names = append(result.names, name)
+		result.fields[name] = m
+	}
+
+	return result, nil
+}
+
+// Section returns the profile of the named section
+func (p *Profile) Section(name string) (SectionProfile, error) {
+	s, found := p.Sections[name]
+	if !found {
+		return SectionProfile{}, fmt.Errorf("section %s: missing: %w", name, ErrInvalidProfile)
+	}
+	return s, nil
+}
+
+// ParseProfile decodes a json selector profile, unknown keys are rejected
+func ParseProfile(r io.Reader) (*Profile, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var p Profile
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%s: %w", err.Error(), ErrInvalidProfile)
+	}
+
+	if p.Version != ProfileVersion {
+		return nil, fmt.Errorf("version %d, expected %d: %w", p.Version, ProfileVersion, ErrInvalidProfile)
+	}
+
+	return &p, nil
+}
+
+// LoadProfile reads the selector profile at path
+func LoadProfile(path string) (*Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := ParseProfile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return p, nil
+}
+
+// DefaultProfile returns the embedded profile matching the current site markup
+func DefaultProfile() *Profile {
+	p, err := ParseProfile(bytes.NewReader(defaultProfile))
+	if err != nil {
+		panic("openblind: default profile: " + err.Error())
+	}
+	return p
+}
@@ -0,0 +1,161 @@
+package openblind
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+const profileFixture = `{
+	"version": 1,
+	"sections": {
+		"reviews": {
+			"list": "#feed",
+			"record": "li",
+			"id": {"attr": "id", "pattern": "^r(\\d+)$"},
+			"fields": {"title": "h2.summary", "pros": "[data-test=pros]", "advice": ".advice"}
+		}
+	}
+}`
+
+func TestProfileCompile(t *testing.T) {
+	p, err := ParseProfile(strings.NewReader(profileFixture))
+	if err != nil {
+		t.Fatalf("ParseProfile() error = %v", err)
+	}
+
+	section, err := p.Section("reviews")
+	if err != nil {
+		t.Fatalf("Section() error = %v", err)
+	}
+
+	s, err := section.Compile("title")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	root, err := html.Parse(strings.NewReader(cssFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, found := Find(root, s.List)
+	if !found {
+		t.Fatal("Find() list not found")
+	}
+
+	var ids []string
+	for _, record := range FindAll(list, s.Record) {
+		id, _ := s.RecordID(record)
+		ids = append(ids, id)
+	}
+
+	if got, want := strings.Join(ids, ","), "1,2,3,4"; got != want {
+		t.Errorf("RecordID() = %q, want %q", got, want)
+	}
+
+	if got := selectIDs(t, root, s.Field("title")); strings.Join(got, ",") != "t1,t2" {
+		t.Errorf("Field() matched %v, want [t1 t2]", got)
+	}
+}
+
+func TestProfileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		fields  []string
+	}{
+		{name: "malformed", profile: `{"version": 1`},
+		{name: "unknown key", profile: `{"version": 1, "selectors": {}}`},
+		{name: "unsupported version", profile: `{"version": 2}`},
+		{name: "missing section", profile: `{"version": 1, "sections": {}}`},
+		{
+			name:    "invalid list",
+			profile: `{"version": 1, "sections": {"reviews": {"list": "li:hover", "record": "li", "id": {"attr": "id", "pattern": "(\\d+)"}}}}`,
+		},
+		{
+			name:    "missing id attr",
+			profile: `{"version": 1, "sections": {"reviews": {"list": "ol", "record": "li", "id": {"pattern": "(\\d+)"}}}}`,
+		},
+		{
+			name:    "id pattern without group",
+			profile: `{"version": 1, "sections": {"reviews": {"list": "ol", "record": "li", "id": {"attr": "id", "pattern": "\\d+"}}}}`,
+		},
+		{
+			name:    "missing field",
+			profile: `{"version": 1, "sections": {"reviews": {"list": "ol", "record": "li", "id": {"attr": "id", "pattern": "(\\d+)"}}}}`,
+			fields:  []string{"title"},
+		},
+		{
+			name:    "invalid field",
+			profile: `{"version": 1, "sections": {"reviews": {"list": "ol", "record": "li", "id": {"attr": "id", "pattern": "(\\d+)"}, "fields": {"title": "h2 >"}}}}`,
+			fields:  []string{"title"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseProfile(strings.NewReader(tt.profile))
+			if err == nil {
+				var section SectionProfile
+				section, err = p.Section("reviews")
+				if err == nil {
+					_, err = section.Compile(tt.fields...)
+				}
+			}
+
+			if !errors.Is(err, ErrInvalidProfile) {
+				t.Errorf("error = %v, want %v", err, ErrInvalidProfile)
+			}
+		})
+	}
+}
+
+func TestDefaultProfile(t *testing.T) {
+	p := DefaultProfile()
+
+	for _, name := range []string{"reviews", "interviews"} {
+		section, err := p.Section(name)
+		if err != nil {
+			t.Fatalf("Section(%q) error = %v", name, err)
+		}
+
+		if _, err := section.Compile(); err != nil {
+			t.Errorf("Compile(%q) error = %v", name, err)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	p, err := ParseProfile(strings.NewReader(profileFixture))
+	if err != nil {
+		t.Fatalf("ParseProfile() error = %v", err)
+	}
+
+	s, err := p.Sections["reviews"].Compile("title", "pros", "advice")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	root, err := html.Parse(strings.NewReader(cssFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Inspection{
+		List:   MatchCount{Name: "list", Selector: "#feed", Nodes: 1},
+		Record: MatchCount{Name: "record", Selector: s.Record.String(), Nodes: 4},
+		Fields: []MatchCount{
+			{Name: "title", Selector: "h2.summary", Nodes: 2, Records: 2},
+			{Name: "pros", Selector: "[data-test=pros]", Nodes: 2, Records: 2},
+			{Name: "advice", Selector: ".advice"},
+		},
+	}
+
+	if diff := cmp.Diff(want, s.Inspect(root)); diff != "" {
+		t.Errorf("Inspect() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,116 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/jacoelho/openblind/reviews"
+)
+
+// Sort is the order the site returns records in
+type Sort string
+
+const (
+	SortDefault Sort = ""
+	SortRecent  Sort = "recent"
+	SortRating  Sort = "rating"
+	SortHelpful Sort = "helpful"
+)
+
+const (
+	paramSortType         = "sort.sortType"
+	paramSortAscending    = "sort.ascending"
+	paramJobTitle         = "filter.jobTitleFTS"
+	paramLocation         = "filter.location"
+	paramEmploymentStatus = "filter.employmentStatus"
+	paramRating           = "filter.overallRating"
+)
+
+var (
+	sortTypes = map[Sort]string{
+		SortRecent:  "RD",
+		SortRating:  "OR",
+		SortHelpful: "HR",
+	}
+
+	employmentStatuses = map[reviews.EmploymentStatus]string{
+		reviews.EmploymentStatusCurrent: "CURRENT",
+		reviews.EmploymentStatusFormer:  "FORMER",
+	}
+
+	ErrInvalidSort             = errors.New("invalid sort")
+	ErrInvalidEmploymentStatus = errors.New("invalid employment status")
+	ErrInvalidRating           = errors.New("invalid rating")
+)
+
+type Options struct {
+	Sort      Sort
+	Ascending bool
+
+	JobTitle         string
+	Location         string
+	EmploymentStatus reviews.EmploymentStatus
+
+	// Rating filters by overall rating, from 1 to 5, zero means no filter
+	Rating int
+}
+
+// NewestFirst reports whether records are returned most recent first, as by the site default
+func (o Options) NewestFirst() bool {
+	switch o.Sort {
+	case SortDefault:
+		return true
+	case SortRecent:
+		return !o.Ascending
+	default:
+		return false
+	}
+}
+
+// Apply returns a copy of u with the sort and filter options set in the query string,
+// parameters already in u not covered by the options are kept
+func Apply(u *url.URL, opts Options) (*url.URL, error) {
+	values := u.Query()
+
+	if opts.Sort != SortDefault {
+		sortType, found := sortTypes[opts.Sort]
+		if !found {
+			return nil, fmt.Errorf("%q: %w", opts.Sort, ErrInvalidSort)
+		}
+
+		values.Set(paramSortType, sortType)
+		values.Set(paramSortAscending, strconv.FormatBool(opts.Ascending))
+	}
+
+	if opts.JobTitle != "" {
+		values.Set(paramJobTitle, opts.JobTitle)
+	}
+
+	if opts.Location != "" {
+		values.Set(paramLocation, opts.Location)
+	}
+
+	if opts.EmploymentStatus != reviews.EmploymentStatusUnknown {
+		status, found := employmentStatuses[opts.EmploymentStatus]
+		if !found {
+			return nil, fmt.Errorf("%q: %w", opts.EmploymentStatus, ErrInvalidEmploymentStatus)
+		}
+
+		values.Set(paramEmploymentStatus, status)
+	}
+
+	if opts.Rating != 0 {
+		if opts.Rating < 1 || opts.Rating > 5 {
+			return nil, fmt.Errorf("%d: %w", opts.Rating, ErrInvalidRating)
+		}
+
+		values.Set(paramRating, strconv.Itoa(opts.Rating))
+	}
+
+	result := *u
+	result.RawQuery = values.Encode()
+
+	return &result, nil
+}
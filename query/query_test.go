@@ -0,0 +1,116 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/jacoelho/openblind/reviews"
+)
+
+func TestApply(t *testing.T) {
+	const base = "https://www.glassdoor.co.uk/Reviews/Tesla-Reviews-E43129.htm"
+
+	tests := []struct {
+		name    string
+		url     string
+		opts    Options
+		want    string
+		wantErr error
+	}{
+		{
+			name: "no options",
+			url:  base,
+			want: base,
+		},
+		{
+			name: "recent first",
+			url:  base,
+			opts: Options{Sort: SortRecent},
+			want: base + "?sort.ascending=false&sort.sortType=RD",
+		},
+		{
+			name: "all filters",
+			url:  base,
+			opts: Options{
+				Sort:             SortRating,
+				Ascending:        true,
+				JobTitle:         "Software Engineer",
+				Location:         "London",
+				EmploymentStatus: reviews.EmploymentStatusFormer,
+				Rating:           2,
+			},
+			want: base + "?filter.employmentStatus=FORMER&filter.jobTitleFTS=Software+Engineer&filter.location=London&filter.overallRating=2&sort.ascending=true&sort.sortType=OR",
+		},
+		{
+			name: "keeps existing parameters",
+			url:  base + "?filter.iso3Language=eng&sort.sortType=OR",
+			opts: Options{Sort: SortHelpful},
+			want: base + "?filter.iso3Language=eng&sort.ascending=false&sort.sortType=HR",
+		},
+		{
+			name:    "invalid sort",
+			url:     base,
+			opts:    Options{Sort: "oldest"},
+			wantErr: ErrInvalidSort,
+		},
+		{
+			name:    "invalid employment status",
+			url:     base,
+			opts:    Options{EmploymentStatus: "contractor"},
+			wantErr: ErrInvalidEmploymentStatus,
+		},
+		{
+			name:    "invalid rating",
+			url:     base,
+			opts:    Options{Rating: 6},
+			wantErr: ErrInvalidRating,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Apply(u, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("Apply() = %q, want %q", got.String(), tt.want)
+			}
+
+			if u.String() != tt.url {
+				t.Errorf("Apply() modified input url: %q", u.String())
+			}
+		})
+	}
+}
+
+func TestNewestFirst(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want bool
+	}{
+		{opts: Options{}, want: true},
+		{opts: Options{Ascending: true}, want: true},
+		{opts: Options{Sort: SortRecent}, want: true},
+		{opts: Options{Sort: SortRecent, Ascending: true}, want: false},
+		{opts: Options{Sort: SortRating}, want: false},
+		{opts: Options{Sort: SortHelpful}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.opts.NewestFirst(); got != tt.want {
+			t.Errorf("NewestFirst(%+v) = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -16,9 +15,45 @@ import (
 // Sun Mar 28 2021 06:27:08 GMT+0100
 const datetimeFormat = "Mon Jan 02 2006 15:04:05 MST-0700"
 
+const sectionName = "reviews"
+
+const (
+	fieldDate           = "date"
+	fieldRating         = "rating"
+	fieldSubRatings     = "subRatings"
+	fieldSubRatingItem  = "subRatingItem"
+	fieldSubRatingLabel = "subRatingLabel"
+	fieldAuthorJobTitle = "authorJobTitle"
+	fieldAuthorLocation = "authorLocation"
+	fieldRecommends     = "recommends"
+	fieldIndicator      = "indicator"
+	fieldTitle          = "title"
+	fieldPros           = "pros"
+	fieldCons           = "cons"
+	fieldAdvice         = "advice"
+)
+
+// Fields lists the field selectors a reviews profile section must define
+var Fields = []string{
+	fieldDate,
+	fieldRating,
+	fieldSubRatings,
+	fieldSubRatingItem,
+	fieldSubRatingLabel,
+	fieldAuthorJobTitle,
+	fieldAuthorLocation,
+	fieldRecommends,
+	fieldIndicator,
+	fieldTitle,
+	fieldPros,
+	fieldCons,
+	fieldAdvice,
+}
+
+var defaultSelectors = mustSelectors(openblind.DefaultProfile())
+
 var (
-	reviewRe               = regexp.MustCompile(`^empReview_(?P<ID>\d+)$`)
-	matcherReviewContainer = openblind.WithIDRe(reviewRe)
+	errListNotFound = errors.New("failed to find reviews")
 
 	ErrParseID     = errors.New("failed to parse id")
 	ErrParseDate   = errors.New("failed to parse date")
@@ -29,6 +64,64 @@ var (
 	ErrParseAdvice = errors.New("failed to parse advice")
 )
 
+// Category identifies a sub-rating dimension independently of its display label
+type Category string
+
+const (
+	CategoryWorkLifeBalance      Category = "workLifeBalance"
+	CategoryCultureAndValues     Category = "cultureAndValues"
+	CategoryDiversityInclusion   Category = "diversityAndInclusion"
+	CategoryCareerOpportunities  Category = "careerOpportunities"
+	CategoryCompensationBenefits Category = "compensationAndBenefits"
+	CategorySeniorManagement     Category = "seniorManagement"
+)
+
+// EmploymentStatus describes the author relation with the employer
+type EmploymentStatus string
+
+const (
+	EmploymentStatusUnknown EmploymentStatus = ""
+	EmploymentStatusCurrent EmploymentStatus = "current"
+	EmploymentStatusFormer  EmploymentStatus = "former"
+)
+
+// Indicator is the tri-state value of the recommendation, outlook and CEO indicators
+type Indicator string
+
+const (
+	IndicatorUnknown  Indicator = ""
+	IndicatorPositive Indicator = "positive"
+	IndicatorNegative Indicator = "negative"
+	IndicatorNeutral  Indicator = "neutral"
+)
+
+var indicatorColours = map[string]Indicator{
+	"green":  IndicatorPositive,
+	"red":    IndicatorNegative,
+	"yellow": IndicatorNeutral,
+}
+
+const anonymousEmployee = "Anonymous Employee"
+
+// Categories lists every sub-rating category in display order
+var Categories = []Category{
+	CategoryWorkLifeBalance,
+	CategoryCultureAndValues,
+	CategoryDiversityInclusion,
+	CategoryCareerOpportunities,
+	CategoryCompensationBenefits,
+	CategorySeniorManagement,
+}
+
+var categoryLabels = map[string]Category{
+	"Work/Life Balance":         CategoryWorkLifeBalance,
+	"Culture & Values":          CategoryCultureAndValues,
+	"Diversity & Inclusion":     CategoryDiversityInclusion,
+	"Career Opportunities":      CategoryCareerOpportunities,
+	"Compensation and Benefits": CategoryCompensationBenefits,
+	"Senior Management":         CategorySeniorManagement,
+}
+
 type Review struct {
 	ID     string    `json:"id,omitempty"`
 	Date   time.Time `json:"date,omitempty"`
@@ -37,177 +130,496 @@ type Review struct {
 	Pros   []string  `json:"pros,omitempty"`
 	Cons   []string  `json:"cons,omitempty"`
 	Advice []string  `json:"advice,omitempty"`
+
+	SubRatings map[Category]float64 `json:"subRatings,omitempty"`
+
+	EmploymentStatus EmploymentStatus `json:"employmentStatus,omitempty"`
+	JobTitle         string           `json:"jobTitle,omitempty"`
+	Location         string           `json:"location,omitempty"`
+
+	Recommends  Indicator `json:"recommends,omitempty"`
+	Outlook     Indicator `json:"outlook,omitempty"`
+	CEOApproval Indicator `json:"ceoApproval,omitempty"`
 }
 
-func parseID(node *html.Node) (string, error) {
-	var value string
+// NewSelectors compiles the reviews section of the profile
+func NewSelectors(p *openblind.Profile) (*openblind.Selectors, error) {
+	section, err := p.Section(sectionName)
+	if err != nil {
+		return nil, err
+	}
 
-	_, found := openblind.Find(node, func(n *html.Node) bool {
-		v, ok := openblind.WithAttr(n, "id")
-		if ok && reviewRe.MatchString(v) {
-			idx := reviewRe.SubexpIndex("ID")
-			value = reviewRe.FindStringSubmatch(v)[idx]
-		}
+	return section.Compile(Fields...)
+}
 
-		return ok
-	})
+func mustSelectors(p *openblind.Profile) *openblind.Selectors {
+	s, err := NewSelectors(p)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// DefaultSelectors returns the reviews selectors of the default profile
+func DefaultSelectors() *openblind.Selectors {
+	return defaultSelectors
+}
+
+// parser reads reviews with the selectors of a profile
+type parser struct {
+	*openblind.Selectors
+}
+
+func newError(record, node *html.Node, field string, m openblind.Matcher, err error) error {
+	return openblind.NewParseError(sectionName, record, node, field, m, err)
+}
+
+func (p parser) parseID(node *html.Node) (string, error) {
+	container, found := openblind.Find(node, p.Record)
 	if !found {
-		return "", ErrParseID
+		return "", newError(node, node, "id", p.Record, ErrParseID)
 	}
 
-	return value, nil
+	id, _ := p.RecordID(container)
+
+	return id, nil
 }
 
-func parseDatetime(node *html.Node) (time.Time, error) {
-	rating, found := openblind.Find(node, openblind.WithClass("date subtle small"))
+func (p parser) parseDatetime(node *html.Node) (time.Time, error) {
+	m := p.Field(fieldDate)
+
+	dateNode, found := openblind.Find(node, m)
 	if !found {
-		return time.Time{}, ErrParseDate
+		return time.Time{}, newError(node, node, "date", m, ErrParseDate)
 	}
 
-	value, found := openblind.AttrValue(rating, "datetime")
+	value, found := openblind.AttrValue(dateNode, "datetime")
 	if !found {
-		return time.Time{}, ErrParseDate
+		return time.Time{}, newError(node, dateNode, "date", m, ErrParseDate)
 	}
 
 	// Split by ( leaving parseable part on the left side
 	// example string: Sun Mar 28 2021 06:27:08 GMT+0100 (British Summer Time)
 	split := strings.Split(value, " (")
 	if len(split) != 2 {
-		return time.Time{}, ErrParseDate
+		return time.Time{}, newError(node, dateNode, "date", m, ErrParseDate)
 	}
 
 	parseTime, err := time.Parse(datetimeFormat, split[0])
 	if err != nil {
-		return time.Time{}, fmt.Errorf("%s: %w", err.Error(), ErrParseDate)
+		return time.Time{}, newError(node, dateNode, "date", m, fmt.Errorf("%s: %w", err.Error(), ErrParseDate))
 	}
 
 	return parseTime, nil
 }
 
-func parseRating(node *html.Node) (float64, error) {
-	rating, found := openblind.Find(node, openblind.WithClass("rating"))
+func (p parser) parseRating(node *html.Node) (float64, error) {
+	m := p.Field(fieldRating)
+
+	rating, found := openblind.Find(node, m)
 	if !found {
-		return 0, ErrParseRating
+		return 0, newError(node, node, "rating", m, ErrParseRating)
 	}
 
 	value, found := openblind.AttrValue(rating, "title")
 	if !found {
-		return 0, ErrParseRating
+		return 0, newError(node, rating, "rating", m, ErrParseRating)
 	}
 
 	parsedRating, err := strconv.ParseFloat(value, 64)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", err.Error(), ErrParseRating)
+		return 0, newError(node, rating, "rating", m, fmt.Errorf("%s: %w", err.Error(), ErrParseRating))
 	}
 
 	return parsedRating, nil
 }
 
-func parseTitle(node *html.Node) ([]string, error) {
-	titleNode, found := openblind.Find(node, openblind.WithClass("h2 summary strong mb-xsm mt-0"))
+// parseSubRatings returns the ratings found for each known category,
+// categories missing from the markup are left out of the result
+func (p parser) parseSubRatings(node *html.Node) map[Category]float64 {
+	container, found := openblind.Find(node, p.Field(fieldSubRatings))
 	if !found {
-		return nil, ErrParseTitle
+		return nil
+	}
+
+	items := openblind.FindAll(container, p.Field(fieldSubRatingItem))
+
+	result := make(map[Category]float64)
+	for _, item := range items {
+		labelNode, found := openblind.Find(item, p.Field(fieldSubRatingLabel))
+		if !found {
+			continue
+		}
+
+		label := strings.Join(openblind.RemoveStrings()(openblind.ExtractText(labelNode)), " ")
+		category, known := categoryLabels[label]
+		if !known {
+			continue
+		}
+
+		rating, err := p.parseRating(item)
+		if err != nil {
+			continue
+		}
+
+		result[category] = rating
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	return result
+}
+
+// splitAuthorJobTitle splits the author job title text into status and title
+// example string: Former Employee, more than 1 year - Global Supply Analyst
+func splitAuthorJobTitle(s string) (EmploymentStatus, string) {
+	s = strings.TrimSpace(s)
+
+	var status, title string
+	if idx := strings.Index(s, " - "); idx >= 0 {
+		status, title = strings.TrimSpace(s[:idx]), strings.TrimSpace(s[idx+3:])
+	} else {
+		status = s
+	}
+
+	var result EmploymentStatus
+	switch {
+	case strings.HasPrefix(status, "Current Employee"):
+		result = EmploymentStatusCurrent
+	case strings.HasPrefix(status, "Former Employee"):
+		result = EmploymentStatusFormer
+	default:
+		// no recognisable status, the whole text is the title
+		if title == "" {
+			title = status
+		} else {
+			title = s
+		}
+	}
+
+	if title == anonymousEmployee {
+		title = ""
+	}
+
+	return result, title
+}
+
+func (p parser) parseAuthor(node *html.Node) (EmploymentStatus, string, string) {
+	var (
+		status   EmploymentStatus
+		title    string
+		location string
+	)
+
+	if titleNode, found := openblind.Find(node, p.Field(fieldAuthorJobTitle)); found {
+		text := openblind.RemoveStrings()(openblind.ExtractText(titleNode))
+		status, title = splitAuthorJobTitle(strings.Join(text, " "))
+	}
+
+	if locationNode, found := openblind.Find(node, p.Field(fieldAuthorLocation)); found {
+		location = strings.Join(openblind.RemoveStrings()(openblind.ExtractText(locationNode)), " ")
+	}
+
+	return status, title, location
+}
+
+// indicatorLabel returns the indicator a label such as "Doesn't Recommend",
+// "Neutral Outlook" or "Approves of CEO" belongs to
+func indicatorLabel(label string) (int, bool) {
+	label = strings.ToLower(label)
+
+	switch {
+	case strings.Contains(label, "recommend"):
+		return 0, true
+	case strings.Contains(label, "outlook"):
+		return 1, true
+	case strings.Contains(label, "ceo"):
+		return 2, true
+	default:
+		return 0, false
+	}
+}
+
+// parseIndicators reads the recommends, outlook and CEO approval indicators valued by
+// the colour class of their sqLed. A full row is read by position so localized pages work,
+// the label next to each sqLed names the indicators of a row missing some of them,
+// those without a known label are left unknown
+// <i class="sqLed middle sm mr-xsm green"></i><span>Recommends</span>
+func (p parser) parseIndicators(node *html.Node) (recommends, outlook, ceo Indicator) {
+	row, found := openblind.Find(node, p.Field(fieldRecommends))
+	if !found {
+		return
+	}
+
+	values := make([]Indicator, 3)
+	leds := openblind.FindAll(row, p.Field(fieldIndicator))
+	for i, led := range leds {
+		slot := i
+		if len(leds) != len(values) {
+			if led.Parent == nil {
+				continue
+			}
+
+			if slot, found = indicatorLabel(strings.Join(openblind.ExtractText(led.Parent), " ")); !found {
+				continue
+			}
+		}
+
+		class, _ := openblind.WithAttr(led, "class")
+		for _, token := range strings.Fields(class) {
+			if indicator, ok := indicatorColours[token]; ok {
+				values[slot] = indicator
+				break
+			}
+		}
+	}
+
+	return values[0], values[1], values[2]
+}
+
+func (p parser) parseTitle(node *html.Node) ([]string, error) {
+	titleNode, found := openblind.Find(node, p.Field(fieldTitle))
+	if !found {
+		return nil, newError(node, node, "title", p.Field(fieldTitle), ErrParseTitle)
 	}
 
 	return openblind.ExtractText(titleNode), nil
 }
 
-func parsePros(node *html.Node) ([]string, error) {
-	pros, found := openblind.Find(node, openblind.WithDataTest("pros"))
+func (p parser) parsePros(node *html.Node) ([]string, error) {
+	pros, found := openblind.Find(node, p.Field(fieldPros))
 	if !found {
-		return nil, ErrParsePros
+		return nil, newError(node, node, "pros", p.Field(fieldPros), ErrParsePros)
 	}
 
 	return openblind.ExtractText(pros), nil
 }
 
-func parseCons(node *html.Node) ([]string, error) {
-	cons, found := openblind.Find(node, openblind.WithDataTest("cons"))
+func (p parser) parseCons(node *html.Node) ([]string, error) {
+	cons, found := openblind.Find(node, p.Field(fieldCons))
 	if !found {
-		return nil, ErrParseCons
+		return nil, newError(node, node, "cons", p.Field(fieldCons), ErrParseCons)
 	}
 
 	return openblind.ExtractText(cons), nil
 }
 
-func parseAdvice(node *html.Node) ([]string, error) {
-	advice, found := openblind.Find(node, openblind.WithDataTest("advice-management"))
+func (p parser) parseAdvice(node *html.Node) ([]string, error) {
+	advice, found := openblind.Find(node, p.Field(fieldAdvice))
 	if !found {
-		return nil, ErrParseAdvice
+		return nil, newError(node, node, "advice", p.Field(fieldAdvice), ErrParseAdvice)
 	}
 
 	return openblind.ExtractText(advice), nil
 }
 
+// field parses a single review field into r, optional fields never fail
+// while a required field failing skips the record in lenient mode
+type field struct {
+	name     string
+	required bool
+	parse    func(p parser, node *html.Node, r *Review) error
+}
+
+var fields = []field{
+	{name: "date", required: true, parse: func(p parser, node *html.Node, r *Review) error {
+		t, err := p.parseDatetime(node)
+		r.Date = t.UTC()
+		return err
+	}},
+	{name: "title", required: true, parse: func(p parser, node *html.Node, r *Review) error {
+		title, err := p.parseTitle(node)
+		r.Title = strings.Join(openblind.FlattenByNewLine(title), ",")
+		return err
+	}},
+	{name: "rating", required: true, parse: func(p parser, node *html.Node, r *Review) (err error) {
+		r.Rating, err = p.parseRating(node)
+		return err
+	}},
+	{name: "pros", required: true, parse: func(p parser, node *html.Node, r *Review) error {
+		pros, err := p.parsePros(node)
+		r.Pros = openblind.FlattenByNewLine(pros)
+		return err
+	}},
+	{name: "cons", required: true, parse: func(p parser, node *html.Node, r *Review) error {
+		cons, err := p.parseCons(node)
+		r.Cons = openblind.FlattenByNewLine(cons)
+		return err
+	}},
+	// not all reviews have advice
+	{name: "advice", parse: func(p parser, node *html.Node, r *Review) error {
+		advice, _ := p.parseAdvice(node)
+		r.Advice = openblind.FlattenByNewLine(advice)
+		return nil
+	}},
+	{name: "subRatings", parse: func(p parser, node *html.Node, r *Review) error {
+		r.SubRatings = p.parseSubRatings(node)
+		return nil
+	}},
+	{name: "author", parse: func(p parser, node *html.Node, r *Review) error {
+		r.EmploymentStatus, r.JobTitle, r.Location = p.parseAuthor(node)
+		return nil
+	}},
+	{name: "indicators", parse: func(p parser, node *html.Node, r *Review) error {
+		r.Recommends, r.Outlook, r.CEOApproval = p.parseIndicators(node)
+		return nil
+	}},
+}
+
+// Options controls how malformed reviews are handled
+type Options struct {
+	// Lenient skips reviews failing a required field instead of stopping the parse
+	Lenient bool
+	// Diagnostic receives the skipped reviews
+	Diagnostic openblind.Diagnostic
+	// Selectors locate the reviews, the default profile is used when nil
+	Selectors *openblind.Selectors
+}
+
+func (o Options) parser() parser {
+	if o.Selectors == nil {
+		return parser{defaultSelectors}
+	}
+	return parser{o.Selectors}
+}
+
+func (o Options) report(err error) {
+	if o.Diagnostic != nil {
+		o.Diagnostic(err)
+	}
+}
+
 func parseReview(node *html.Node) (Review, error) {
-	var result Review
+	return parseReviewOptions(node, Options{})
+}
+
+func parseReviewOptions(node *html.Node, opts Options) (Review, error) {
+	p := opts.parser()
 
-	id, err := parseID(node)
+	id, err := p.parseID(node)
 	if err != nil {
-		return result, err
+		return Review{}, err
 	}
 
-	reviewTime, err := parseDatetime(node)
-	if err != nil {
-		return result, err
+	result := Review{ID: id}
+	for _, f := range fields {
+		if err := f.parse(p, node, &result); err != nil && f.required {
+			return Review{}, openblind.WithRecordID(err, id)
+		}
 	}
 
-	title, err := parseTitle(node)
-	if err != nil {
-		return result, err
+	return result, nil
+}
+
+// recordHandler parses each record node calling fn with the result,
+// in lenient mode failing records are reported and skipped
+func recordHandler(opts Options, fn func(Review) error) func(*html.Node) error {
+	return func(node *html.Node) error {
+		res, err := parseReviewOptions(node, opts)
+		if err != nil {
+			if !opts.Lenient {
+				return err
+			}
+
+			opts.report(err)
+			return nil
+		}
+
+		return fn(res)
 	}
+}
 
-	rating, err := parseRating(node)
+// ParseFunc calls fn for each review as soon as it is parsed,
+// parsing stops at the first error returned by fn
+func ParseFunc(r io.Reader, fn func(Review) error) error {
+	return ParseFuncOptions(r, Options{}, fn)
+}
+
+// ParseFuncOptions is like ParseFunc with malformed reviews handled according to opts
+func ParseFuncOptions(r io.Reader, opts Options, fn func(Review) error) error {
+	root, err := html.Parse(r)
 	if err != nil {
-		return result, err
+		return err
 	}
 
-	pros, err := parsePros(node)
-	if err != nil {
-		return result, err
+	return ParseNode(root, opts, fn)
+}
+
+// ParseNode is like ParseFuncOptions for an already parsed document
+func ParseNode(root *html.Node, opts Options, fn func(Review) error) error {
+	p := opts.parser()
+
+	list, ok := openblind.Find(root, p.List)
+	if !ok {
+		return openblind.NewParseError(sectionName, nil, nil, "list", p.List, errListNotFound)
 	}
 
-	cons, err := parseCons(node)
-	if err != nil {
-		return result, err
+	handle := recordHandler(opts, fn)
+	for _, review := range openblind.FindAll(list, p.Record) {
+		if err := handle(review); err != nil {
+			return err
+		}
 	}
 
-	// not all reviews have advice
-	advice, _ := parseAdvice(node)
+	return nil
+}
+
+// ParseStream is like ParseFunc but tokenizes the document in a single pass
+// without building the whole DOM, only the current review is kept in memory
+func ParseStream(r io.Reader, fn func(Review) error) error {
+	return ParseStreamOptions(r, Options{}, fn)
+}
+
+// ParseStreamOptions is like ParseStream with malformed reviews handled according to opts
+func ParseStreamOptions(r io.Reader, opts Options, fn func(Review) error) error {
+	p := opts.parser()
 
-	return Review{
-		ID:     id,
-		Date:   reviewTime.UTC(),
-		Title:  strings.Join(openblind.FlattenByNewLine(title), ","),
-		Rating: rating,
-		Pros:   openblind.FlattenByNewLine(pros),
-		Cons:   openblind.FlattenByNewLine(cons),
-		Advice: openblind.FlattenByNewLine(advice),
-	}, nil
+	err := openblind.StreamNodes(r, p.List, p.Record, recordHandler(opts, fn))
+	if errors.Is(err, openblind.ErrListNotFound) {
+		return openblind.NewParseError(sectionName, nil, nil, "list", p.List, errListNotFound)
+	}
+
+	return err
 }
 
 func Parse(r io.Reader) ([]Review, error) {
-	root, err := html.Parse(r)
+	result := make([]Review, 0)
+
+	err := ParseFunc(r, func(review Review) error {
+		result = append(result, review)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	list, ok := openblind.Find(root, openblind.WithID("ReviewsFeed"))
-	if !ok {
-		return nil, errors.New("failed to find reviews")
-	}
-
-	reviews := openblind.FindAll(list, matcherReviewContainer)
+	return result, nil
+}
 
-	result := make([]Review, 0, len(reviews))
-	for _, review := range reviews {
-		res, err := parseReview(review)
-		if err != nil {
-			return nil, err
-		}
+// ParseLenient parses every well formed review skipping the malformed ones,
+// the errors of skipped reviews are returned as diagnostics
+func ParseLenient(r io.Reader) ([]Review, []error, error) {
+	var (
+		result      = make([]Review, 0)
+		diagnostics []error
+	)
+
+	opts := Options{
+		Lenient: true,
+		Diagnostic: func(err error) {
+			diagnostics = append(diagnostics, err)
+		},
+	}
 
-		result = append(result, res)
+	err := ParseFuncOptions(r, opts, func(review Review) error {
+		result = append(result, review)
+		return nil
+	})
+	if err != nil {
+		return nil, diagnostics, err
 	}
 
-	return result, nil
+	return result, diagnostics, nil
 }
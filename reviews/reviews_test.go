@@ -1,11 +1,16 @@
 package reviews
 
 import (
+	"errors"
+	"io"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jacoelho/openblind"
 	"golang.org/x/net/html"
 )
 
@@ -148,6 +153,34 @@ const fixture = `<li class="empReview cf " id="empReview_45005756" data-brandvie
 </div>
 </li>`
 
+func fixtureReview(t *testing.T) Review {
+	t.Helper()
+
+	return Review{
+		ID:     "45005756",
+		Date:   mustParseTime(t, "2021-04-04T16:00:47Z"),
+		Title:  `"Great Company"`,
+		Rating: 5.0,
+		Pros:   []string{"Amazing work, very involved in day-to-day details of the company."},
+		Cons:   []string{"Work-life balance is not the best."},
+		Advice: nil,
+		SubRatings: map[Category]float64{
+			CategoryWorkLifeBalance:      2.0,
+			CategoryCultureAndValues:     5.0,
+			CategoryDiversityInclusion:   5.0,
+			CategoryCareerOpportunities:  5.0,
+			CategoryCompensationBenefits: 3.0,
+			CategorySeniorManagement:     4.0,
+		},
+		EmploymentStatus: EmploymentStatusCurrent,
+		JobTitle:         "Global Supply Analyst",
+		Location:         "San Francisco, CA",
+		Recommends:       IndicatorPositive,
+		Outlook:          IndicatorPositive,
+		CEOApproval:      IndicatorPositive,
+	}
+}
+
 func TestParseInterview(t *testing.T) {
 	root, err := html.Parse(strings.NewReader(fixture))
 	if err != nil {
@@ -159,21 +192,424 @@ func TestParseInterview(t *testing.T) {
 		t.Fatalf("failed to parse: %v", err)
 	}
 
-	want := Review{
-		ID:     "45005756",
-		Date:   mustParseTime(t, "2021-04-04T16:00:47Z"),
-		Title:  `"Great Company"`,
-		Rating: 5.0,
-		Pros:   []string{"Amazing work, very involved in day-to-day details of the company."},
-		Cons:   []string{"Work-life balance is not the best."},
-		Advice: nil,
+	if diff := cmp.Diff(fixtureReview(t), got); diff != "" {
+		t.Errorf("parseReview() mismatch (-want +got):\n%s", diff)
 	}
+}
 
-	if diff := cmp.Diff(want, got); diff != "" {
+func TestParseReviewClassChanges(t *testing.T) {
+	// reordered and additional utility classes must not break parsing
+	changed := strings.NewReplacer(
+		`class="date subtle small"`, `class="small date css-1x2y3z"`,
+		`class="h2 summary strong mb-xsm mt-0"`, `class="summary h2 mt-sm"`,
+		`class="authorJobTitle middle "`, `class="authorJobTitle"`,
+		`class="row reviewBodyCell recommends"`, `class="recommends row"`,
+		`class="subRatings module subRatings__SubRatingsStyles__subRatings"`, `class="subRatings subRatings__SubRatingsStyles__subRatings__x1"`,
+	).Replace(fixture)
+
+	root, err := html.Parse(strings.NewReader(changed))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	got, err := parseReview(root)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if diff := cmp.Diff(fixtureReview(t), got); diff != "" {
 		t.Errorf("parseReview() mismatch (-want +got):\n%s", diff)
 	}
 }
 
+func TestParseReviewError(t *testing.T) {
+	broken := strings.Replace(fixture, `data-test="cons"`, `data-test="removed"`, 1)
+
+	root, err := html.Parse(strings.NewReader(broken))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	record, _ := openblind.Find(root, DefaultSelectors().Record)
+
+	_, err = parseReview(record)
+	if !errors.Is(err, ErrParseCons) {
+		t.Fatalf("parseReview() error = %v, want %v", err, ErrParseCons)
+	}
+
+	var parseErr *openblind.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("parseReview() error = %T, want *openblind.ParseError", err)
+	}
+
+	want := openblind.ParseError{
+		Section:  "reviews",
+		RecordID: "45005756",
+		Field:    "cons",
+		Matcher:  `[data-test="cons"]`,
+		Path:     "li#empReview_45005756.empReview.cf",
+		Err:      ErrParseCons,
+	}
+
+	if diff := cmp.Diff(want, *parseErr, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("parseReview() error mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseSubRatings(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[Category]float64
+	}{
+		{
+			name:  "no sub ratings",
+			input: `<div class="gdReview"></div>`,
+			want:  nil,
+		},
+		{
+			name: "partial sub ratings",
+			input: `<div class="subRatings module subRatings__SubRatingsStyles__subRatings">
+	<ul class="undecorated">
+		<li>
+			<div class="minor">Work/Life Balance</div>
+			<span class="rating"><span title="2.0"></span></span>
+		</li>
+		<li>
+			<div class="minor">Senior Management</div>
+			<span class="rating"><span title="4.0"></span></span>
+		</li>
+		<li>
+			<div class="minor">Unknown Category</div>
+			<span class="rating"><span title="1.0"></span></span>
+		</li>
+		<li>
+			<div class="minor">Culture &amp; Values</div>
+		</li>
+	</ul>
+</div>`,
+			want: map[Category]float64{
+				CategoryWorkLifeBalance:  2.0,
+				CategorySeniorManagement: 4.0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			got := parser{DefaultSelectors()}.parseSubRatings(root)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseSubRatings() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseAuthor(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantStatus   EmploymentStatus
+		wantTitle    string
+		wantLocation string
+	}{
+		{
+			name:         "current employee with location",
+			input:        `<span class="authorInfo"><span class="authorJobTitle middle ">Current Employee - Global Supply Analyst</span>&nbsp;<span class="middle">in <span class="authorLocation">San Francisco, CA</span></span></span>`,
+			wantStatus:   EmploymentStatusCurrent,
+			wantTitle:    "Global Supply Analyst",
+			wantLocation: "San Francisco, CA",
+		},
+		{
+			name:       "former employee with tenure and no location",
+			input:      `<span class="authorInfo"><span class="authorJobTitle middle ">Former Employee, more than 1 year - Sales Advisor</span></span>`,
+			wantStatus: EmploymentStatusFormer,
+			wantTitle:  "Sales Advisor",
+		},
+		{
+			name:         "anonymous current employee",
+			input:        `<span class="authorInfo"><span class="authorJobTitle middle ">Current Employee - Anonymous Employee</span>&nbsp;<span class="middle">in <span class="authorLocation">Berlin</span></span></span>`,
+			wantStatus:   EmploymentStatusCurrent,
+			wantLocation: "Berlin",
+		},
+		{
+			name:  "anonymous employee without status",
+			input: `<span class="authorInfo"><span class="authorJobTitle middle ">Anonymous Employee</span></span>`,
+		},
+		{
+			name:      "title without status",
+			input:     `<span class="authorInfo"><span class="authorJobTitle middle ">Software Engineer</span></span>`,
+			wantTitle: "Software Engineer",
+		},
+		{
+			name:  "missing author",
+			input: `<div class="gdReview"></div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			status, title, location := parser{DefaultSelectors()}.parseAuthor(root)
+			if status != tt.wantStatus {
+				t.Errorf("parseAuthor() status = %q, want %q", status, tt.wantStatus)
+			}
+			if title != tt.wantTitle {
+				t.Errorf("parseAuthor() title = %q, want %q", title, tt.wantTitle)
+			}
+			if location != tt.wantLocation {
+				t.Errorf("parseAuthor() location = %q, want %q", location, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestParseIndicators(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		wantRecommends Indicator
+		wantOutlook    Indicator
+		wantCEO        Indicator
+	}{
+		{
+			name: "mixed indicators",
+			input: `<div class="row reviewBodyCell recommends">
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm red"></i><span>Doesn't Recommend</span></div>
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm yellow"></i><span>Neutral Outlook</span></div>
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm green"></i><span>Approves of CEO</span></div>
+</div>`,
+			wantRecommends: IndicatorNegative,
+			wantOutlook:    IndicatorNeutral,
+			wantCEO:        IndicatorPositive,
+		},
+		{
+			name: "localized labels",
+			input: `<div class="row reviewBodyCell recommends">
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm red"></i><span>Empfiehlt nicht</span></div>
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm yellow"></i><span>Neutrale Aussichten</span></div>
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm green"></i><span>Befürwortet den CEO</span></div>
+</div>`,
+			wantRecommends: IndicatorNegative,
+			wantOutlook:    IndicatorNeutral,
+			wantCEO:        IndicatorPositive,
+		},
+		{
+			name: "unknown colour",
+			input: `<div class="row reviewBodyCell recommends">
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm green"></i><span>Recommends</span></div>
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm light"></i><span>No opinion of CEO</span></div>
+</div>`,
+			wantRecommends: IndicatorPositive,
+		},
+		{
+			name: "only ceo indicator",
+			input: `<div class="row reviewBodyCell recommends">
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm red"></i><span>Disapproves of CEO</span></div>
+</div>`,
+			wantCEO: IndicatorNegative,
+		},
+		{
+			name: "missing label",
+			input: `<div class="row reviewBodyCell recommends">
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm green"></i></div>
+	<div class="col-sm-4 d-flex align-items-center"><i class="sqLed middle sm mr-xsm yellow"></i><span>Neutral Outlook</span></div>
+</div>`,
+			wantOutlook: IndicatorNeutral,
+		},
+		{
+			name:  "missing indicators",
+			input: `<div class="gdReview"></div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+
+			recommends, outlook, ceo := parser{DefaultSelectors()}.parseIndicators(root)
+			if recommends != tt.wantRecommends {
+				t.Errorf("parseIndicators() recommends = %q, want %q", recommends, tt.wantRecommends)
+			}
+			if outlook != tt.wantOutlook {
+				t.Errorf("parseIndicators() outlook = %q, want %q", outlook, tt.wantOutlook)
+			}
+			if ceo != tt.wantCEO {
+				t.Errorf("parseIndicators() ceo = %q, want %q", ceo, tt.wantCEO)
+			}
+		})
+	}
+}
+
+// fixturePage returns a page with n copies of the fixture, each with its own id
+func fixturePage(n int) string {
+	var sb strings.Builder
+
+	sb.WriteString(`<html><body><div id="ReviewsFeed"><ol>`)
+	for i := 0; i < n; i++ {
+		sb.WriteString(strings.ReplaceAll(fixture, "45005756", strconv.Itoa(45005756+i)))
+	}
+	sb.WriteString(`</ol></div></body></html>`)
+
+	return sb.String()
+}
+
+func TestParseStream(t *testing.T) {
+	page := fixturePage(3)
+
+	want, err := Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(want) != 3 {
+		t.Fatalf("Parse() got %d records, want 3", len(want))
+	}
+
+	var got []Review
+	err = ParseStream(strings.NewReader(page), func(v Review) error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseStream() error = %v", err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseStream() mismatch (-Parse +ParseStream):\n%s", diff)
+	}
+}
+
+func TestParseStreamListNotFound(t *testing.T) {
+	err := ParseStream(strings.NewReader(`<html><body></body></html>`), func(Review) error {
+		return nil
+	})
+	if !errors.Is(err, errListNotFound) {
+		t.Errorf("ParseStream() error = %v, want %v", err, errListNotFound)
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	// the first record is missing a required field
+	page := strings.Replace(fixturePage(3), `data-test="cons"`, `data-test="removed"`, 1)
+
+	if _, err := Parse(strings.NewReader(page)); !errors.Is(err, ErrParseCons) {
+		t.Fatalf("Parse() error = %v, want %v", err, ErrParseCons)
+	}
+
+	got, diagnostics, err := ParseLenient(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("ParseLenient() error = %v", err)
+	}
+
+	var ids []string
+	for _, v := range got {
+		ids = append(ids, v.ID)
+	}
+
+	if diff := cmp.Diff([]string{"45005757", "45005758"}, ids); diff != "" {
+		t.Errorf("ParseLenient() ids mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("ParseLenient() got %d diagnostics, want 1", len(diagnostics))
+	}
+
+	var parseErr *openblind.ParseError
+	if !errors.As(diagnostics[0], &parseErr) || !errors.Is(parseErr, ErrParseCons) {
+		t.Fatalf("ParseLenient() diagnostic = %v, want %v", diagnostics[0], ErrParseCons)
+	}
+
+	if parseErr.RecordID != "45005756" || parseErr.Field != "cons" {
+		t.Errorf("ParseLenient() diagnostic = %+v", parseErr)
+	}
+
+	var streamed []Review
+	err = ParseStreamOptions(strings.NewReader(page), Options{Lenient: true}, func(v Review) error {
+		streamed = append(streamed, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseStreamOptions() error = %v", err)
+	}
+
+	if diff := cmp.Diff(got, streamed); diff != "" {
+		t.Errorf("ParseStreamOptions() mismatch (-ParseLenient +ParseStreamOptions):\n%s", diff)
+	}
+}
+
+func TestParseSelectors(t *testing.T) {
+	// a redesign renaming the pros marker only needs a profile change
+	page := strings.Replace(fixturePage(1), `data-test="pros"`, `class="pros-v2"`, 1)
+
+	if _, err := Parse(strings.NewReader(page)); !errors.Is(err, ErrParsePros) {
+		t.Fatalf("Parse() error = %v, want %v", err, ErrParsePros)
+	}
+
+	profile := openblind.DefaultProfile()
+	profile.Sections["reviews"].Fields["pros"] = ".pros-v2"
+
+	selectors, err := NewSelectors(profile)
+	if err != nil {
+		t.Fatalf("NewSelectors() error = %v", err)
+	}
+
+	var got []Review
+	err = ParseFuncOptions(strings.NewReader(page), Options{Selectors: selectors}, func(v Review) error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseFuncOptions() error = %v", err)
+	}
+
+	if diff := cmp.Diff([]Review{fixtureReview(t)}, got); diff != "" {
+		t.Errorf("ParseFuncOptions() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// BenchmarkParse compares building the whole DOM with streaming the records
+func BenchmarkParse(b *testing.B) {
+	page := fixturePage(50)
+
+	parsers := []struct {
+		name  string
+		parse func(r io.Reader) error
+	}{
+		{name: "dom", parse: func(r io.Reader) error {
+			_, err := Parse(r)
+			return err
+		}},
+		{name: "stream", parse: func(r io.Reader) error {
+			return ParseStream(r, func(Review) error { return nil })
+		}},
+	}
+
+	for _, p := range parsers {
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if err := p.parse(strings.NewReader(page)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func mustParseTime(t *testing.T, s string) time.Time {
 	t.Helper()
 
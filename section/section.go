@@ -0,0 +1,83 @@
+package section
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jacoelho/openblind"
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+	"golang.org/x/net/html"
+)
+
+// Section is the kind of page being parsed
+type Section string
+
+const (
+	Auto       Section = "auto"
+	Interviews Section = "interviews"
+	Reviews    Section = "reviews"
+)
+
+var ErrUnknownSection = errors.New("unknown section")
+
+// DetectError describes the landmarks found when the section could not be detected
+type DetectError struct {
+	Found []Section
+}
+
+func (e *DetectError) Error() string {
+	if len(e.Found) == 0 {
+		return "failed to detect section: found neither interview list nor reviews feed"
+	}
+
+	found := make([]string, len(e.Found))
+	for i, s := range e.Found {
+		found[i] = string(s)
+	}
+
+	return fmt.Sprintf("failed to detect section: ambiguous document, found %s", strings.Join(found, ", "))
+}
+
+func (e *DetectError) Is(target error) bool {
+	return target == ErrUnknownSection
+}
+
+// FromURL returns the section from the url path
+// example: /Interview/Tesla-Interview-Questions-E43129.htm, /Reviews/Tesla-Reviews-E43129.htm
+func FromURL(u *url.URL) (Section, bool) {
+	switch {
+	case strings.Contains(u.Path, "/Interview/"):
+		return Interviews, true
+	case strings.Contains(u.Path, "/Reviews/"):
+		return Reviews, true
+	default:
+		return "", false
+	}
+}
+
+// Detect returns the section of the document based on its landmark nodes
+func Detect(root *html.Node) (Section, error) {
+	return DetectSelectors(root, interviews.DefaultSelectors(), reviews.DefaultSelectors())
+}
+
+// DetectSelectors is like Detect using the list selectors of a profile
+func DetectSelectors(root *html.Node, interviewSelectors, reviewSelectors *openblind.Selectors) (Section, error) {
+	var found []Section
+
+	if _, ok := openblind.Find(root, interviewSelectors.List); ok {
+		found = append(found, Interviews)
+	}
+
+	if _, ok := openblind.Find(root, reviewSelectors.List); ok {
+		found = append(found, Reviews)
+	}
+
+	if len(found) != 1 {
+		return "", &DetectError{Found: found}
+	}
+
+	return found[0], nil
+}
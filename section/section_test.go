@@ -0,0 +1,104 @@
+package section
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestFromURL(t *testing.T) {
+	tests := []struct {
+		url       string
+		want      Section
+		wantFound bool
+	}{
+		{
+			url:       "https://www.glassdoor.co.uk/Interview/Tesla-Interview-Questions-E43129.htm",
+			want:      Interviews,
+			wantFound: true,
+		},
+		{
+			url:       "https://www.glassdoor.co.uk/Reviews/Tesla-Reviews-E43129_P2.htm",
+			want:      Reviews,
+			wantFound: true,
+		},
+		{
+			url: "https://www.glassdoor.co.uk/Overview/Working-at-Tesla-EI_IE43129.htm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, found := FromURL(u)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("FromURL() = %q, %v, want %q, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Section
+		wantErr error
+		found   []Section
+	}{
+		{
+			name:  "interviews",
+			input: `<div data-test="InterviewList"></div>`,
+			want:  Interviews,
+		},
+		{
+			name:  "reviews",
+			input: `<div id="ReviewsFeed"></div>`,
+			want:  Reviews,
+		},
+		{
+			name:    "both",
+			input:   `<div data-test="InterviewList"></div><div id="ReviewsFeed"></div>`,
+			wantErr: ErrUnknownSection,
+			found:   []Section{Interviews, Reviews},
+		},
+		{
+			name:    "none",
+			input:   `<div></div>`,
+			wantErr: ErrUnknownSection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Detect(root)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Detect() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+
+			var detectErr *DetectError
+			if errors.As(err, &detectErr) {
+				if diff := cmp.Diff(tt.found, detectErr.Found); diff != "" {
+					t.Errorf("Detect() found mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
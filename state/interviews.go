@@ -0,0 +1,219 @@
+package state
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/jacoelho/openblind/interviews"
+	"golang.org/x/net/html"
+)
+
+var interviewTypes = map[string]struct{}{
+	"EmployerInterview":   {},
+	"EmployerInterviewRG": {},
+}
+
+var (
+	offers = map[string]interviews.Offer{
+		"ACCEPTED_OFFER": interviews.OfferAccepted,
+		"DECLINED_OFFER": interviews.OfferDeclined,
+		"NO_OFFER":       interviews.OfferNone,
+	}
+	experiences = map[string]interviews.Experience{
+		"POSITIVE": interviews.ExperiencePositive,
+		"NEUTRAL":  interviews.ExperienceNeutral,
+		"NEGATIVE": interviews.ExperienceNegative,
+	}
+	difficulties = map[string]interviews.Difficulty{
+		"EASY":      interviews.DifficultyEasy,
+		"AVERAGE":   interviews.DifficultyAverage,
+		"DIFFICULT": interviews.DifficultyDifficult,
+	}
+)
+
+type interviewEntity struct {
+	InterviewID        int64         `json:"interviewId"`
+	ReviewDateTime     string        `json:"reviewDateTime"`
+	JobTitle           *entityRef    `json:"jobTitle"`
+	ApplicationDetails string        `json:"applicationDetails"`
+	ProcessDescription string        `json:"processDescription"`
+	UserQuestions      []questionRef `json:"userQuestions"`
+
+	Outcome    string `json:"outcome"`
+	Experience string `json:"experience"`
+	Difficulty string `json:"difficulty"`
+}
+
+// questionRef is either a reference to a question entity or the question inlined
+type questionRef struct {
+	Ref         string `json:"__ref"`
+	QuestionID  int64  `json:"questionId"`
+	Question    string `json:"question"`
+	URL         string `json:"url"`
+	AnswerCount int    `json:"answerCount"`
+}
+
+// question returns the referenced question, its link resolved against base
+func (c *Cache) question(r questionRef, base *url.URL) (interviews.Question, bool) {
+	if r.Ref != "" {
+		if found, err := c.Get(r.Ref, &r); !found || err != nil {
+			return interviews.Question{}, false
+		}
+	}
+
+	if r.Question == "" {
+		return interviews.Question{}, false
+	}
+
+	var id string
+	if r.QuestionID != 0 {
+		id = strconv.FormatInt(r.QuestionID, 10)
+	}
+
+	return interviews.Question{
+		ID:      id,
+		Text:    r.Question,
+		URL:     interviews.ResolveURL(base, r.URL),
+		Answers: r.AnswerCount,
+	}, true
+}
+
+func toInterview(c *Cache, key string, entity interviewEntity, base *url.URL) (interviews.Interview, error) {
+	if entity.InterviewID == 0 {
+		return interviews.Interview{}, newError("interviews", key, "id", interviews.ErrParseID)
+	}
+
+	id := strconv.FormatInt(entity.InterviewID, 10)
+
+	// featured interviews don't have a datetime
+	if entity.ReviewDateTime == "" {
+		return interviews.Interview{}, newError("interviews", id, "date", interviews.ErrNoDateTime)
+	}
+
+	date, err := parseDateTime(entity.ReviewDateTime)
+	if err != nil {
+		return interviews.Interview{}, newError("interviews", id, "date", interviews.ErrParseDate)
+	}
+
+	var title string
+	if jobTitle := c.label(entity.JobTitle); jobTitle != "" {
+		title = jobTitle + " Interview"
+	}
+
+	result := interviews.Interview{
+		ID:          id,
+		Date:        date,
+		Title:       title,
+		Application: lines(entity.ApplicationDetails),
+		Process:     lines(entity.ProcessDescription),
+		Offer:       offers[entity.Outcome],
+		Experience:  experiences[entity.Experience],
+		Difficulty:  difficulties[entity.Difficulty],
+	}
+
+	for _, ref := range entity.UserQuestions {
+		if question, found := c.question(ref, base); found {
+			result.Questions = append(result.Questions, question)
+		}
+	}
+
+	switch {
+	case result.Title == "":
+		return interviews.Interview{}, newError("interviews", id, "title", interviews.ErrParseTitle)
+	case result.Application == nil:
+		return interviews.Interview{}, newError("interviews", id, "application", interviews.ErrParseApplication)
+	case result.Process == nil:
+		return interviews.Interview{}, newError("interviews", id, "process", interviews.ErrParseProcess)
+	case result.Questions == nil:
+		return interviews.Interview{}, newError("interviews", id, "questions", interviews.ErrParseQuestions)
+	}
+
+	return result, nil
+}
+
+// InterviewsFunc calls fn for each interview entity in the cache in document order,
+// featured interviews are skipped and malformed entities are handled according to opts,
+// question links are resolved against opts.BaseURL
+func InterviewsFunc(c *Cache, opts interviews.Options, fn func(interviews.Interview) error) error {
+	for _, key := range c.Keys() {
+		if _, found := interviewTypes[c.Typename(key)]; !found {
+			continue
+		}
+
+		var entity interviewEntity
+		_, err := c.Get(key, &entity)
+		if err != nil {
+			err = newError("interviews", key, "", err)
+		}
+
+		var interview interviews.Interview
+		if err == nil {
+			interview, err = toInterview(c, key, entity, opts.BaseURL)
+		}
+
+		switch {
+		case errors.Is(err, interviews.ErrNoDateTime):
+			continue
+		case err != nil && opts.Lenient:
+			if opts.Diagnostic != nil {
+				opts.Diagnostic(err)
+			}
+			continue
+		case err != nil:
+			return err
+		}
+
+		if err := fn(interview); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasInterviews reports whether the cache holds any interview entity
+func HasInterviews(c *Cache) bool {
+	for _, key := range c.Keys() {
+		if _, found := interviewTypes[c.Typename(key)]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseInterviewsFunc extracts the interviews from the embedded application state,
+// falling back to the DOM parser when the document has no valid state or no interviews in it.
+// opts.Selectors only apply to the DOM parser, the state takes precedence when present
+func ParseInterviewsFunc(r io.Reader, opts interviews.Options, fn func(interviews.Interview) error) error {
+	root, err := html.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	cache, err := Find(root)
+	switch {
+	case err == nil && HasInterviews(cache):
+		return InterviewsFunc(cache, opts, fn)
+	case err == nil, fallback(err):
+		return interviews.ParseNode(root, opts, fn)
+	default:
+		return err
+	}
+}
+
+// ParseInterviews is like ParseInterviewsFunc collecting every interview
+func ParseInterviews(r io.Reader) ([]interviews.Interview, error) {
+	result := make([]interviews.Interview, 0)
+
+	err := ParseInterviewsFunc(r, interviews.Options{}, func(interview interviews.Interview) error {
+		result = append(result, interview)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
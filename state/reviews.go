@@ -0,0 +1,232 @@
+package state
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacoelho/openblind"
+	"github.com/jacoelho/openblind/reviews"
+	"golang.org/x/net/html"
+)
+
+// dateTimeFormat is the format of entity dates, in UTC with optional fractional seconds
+const dateTimeFormat = "2006-01-02T15:04:05"
+
+var reviewTypes = map[string]struct{}{
+	"EmployerReview":   {},
+	"EmployerReviewRG": {},
+}
+
+var (
+	indicators = map[string]reviews.Indicator{
+		"POSITIVE": reviews.IndicatorPositive,
+		"NEGATIVE": reviews.IndicatorNegative,
+		"NEUTRAL":  reviews.IndicatorNeutral,
+	}
+	ceoIndicators = map[string]reviews.Indicator{
+		"APPROVE":    reviews.IndicatorPositive,
+		"DISAPPROVE": reviews.IndicatorNegative,
+		"NO_OPINION": reviews.IndicatorNeutral,
+	}
+)
+
+type reviewEntity struct {
+	ReviewID       int64   `json:"reviewId"`
+	ReviewDateTime string  `json:"reviewDateTime"`
+	Summary        string  `json:"summary"`
+	RatingOverall  float64 `json:"ratingOverall"`
+	Pros           string  `json:"pros"`
+	Cons           string  `json:"cons"`
+	Advice         string  `json:"advice"`
+
+	RatingWorkLifeBalance         float64 `json:"ratingWorkLifeBalance"`
+	RatingCultureAndValues        float64 `json:"ratingCultureAndValues"`
+	RatingDiversityAndInclusion   float64 `json:"ratingDiversityAndInclusion"`
+	RatingCareerOpportunities     float64 `json:"ratingCareerOpportunities"`
+	RatingCompensationAndBenefits float64 `json:"ratingCompensationAndBenefits"`
+	RatingSeniorLeadership        float64 `json:"ratingSeniorLeadership"`
+
+	IsCurrentJob *bool      `json:"isCurrentJob"`
+	JobTitle     *entityRef `json:"jobTitle"`
+	Location     *entityRef `json:"location"`
+
+	RatingRecommendToFriend string `json:"ratingRecommendToFriend"`
+	RatingBusinessOutlook   string `json:"ratingBusinessOutlook"`
+	RatingCeo               string `json:"ratingCeo"`
+}
+
+// lines splits text the same way the DOM parsers split paragraphs
+func lines(s string) []string {
+	return openblind.RemoveStrings()(openblind.FlattenByNewLine([]string{s}))
+}
+
+// title quotes the review summary the way the page shows it,
+// so titles read from the state and from the DOM are equal
+func title(summary string) string {
+	summary = strings.Trim(strings.TrimSpace(summary), `"“”`)
+	if summary == "" {
+		return ""
+	}
+
+	return `"` + summary + `"`
+}
+
+func parseDateTime(s string) (time.Time, error) {
+	return time.Parse(dateTimeFormat, s)
+}
+
+func newError(section, key, field string, err error) error {
+	return &openblind.ParseError{Section: section, RecordID: key, Field: field, Err: err}
+}
+
+func toReview(c *Cache, key string, entity reviewEntity) (reviews.Review, error) {
+	if entity.ReviewID == 0 {
+		return reviews.Review{}, newError("reviews", key, "id", reviews.ErrParseID)
+	}
+
+	id := strconv.FormatInt(entity.ReviewID, 10)
+
+	date, err := parseDateTime(entity.ReviewDateTime)
+	if err != nil {
+		return reviews.Review{}, newError("reviews", id, "date", reviews.ErrParseDate)
+	}
+
+	result := reviews.Review{
+		ID:     id,
+		Date:   date,
+		Title:  title(entity.Summary),
+		Rating: entity.RatingOverall,
+		Pros:   lines(entity.Pros),
+		Cons:   lines(entity.Cons),
+		Advice: lines(entity.Advice),
+
+		JobTitle: c.label(entity.JobTitle),
+		Location: c.label(entity.Location),
+
+		Recommends:  indicators[entity.RatingRecommendToFriend],
+		Outlook:     indicators[entity.RatingBusinessOutlook],
+		CEOApproval: ceoIndicators[entity.RatingCeo],
+	}
+
+	switch {
+	case result.Title == "":
+		return reviews.Review{}, newError("reviews", id, "title", reviews.ErrParseTitle)
+	case result.Rating == 0:
+		return reviews.Review{}, newError("reviews", id, "rating", reviews.ErrParseRating)
+	case result.Pros == nil:
+		return reviews.Review{}, newError("reviews", id, "pros", reviews.ErrParsePros)
+	case result.Cons == nil:
+		return reviews.Review{}, newError("reviews", id, "cons", reviews.ErrParseCons)
+	}
+
+	if entity.IsCurrentJob != nil {
+		result.EmploymentStatus = reviews.EmploymentStatusFormer
+		if *entity.IsCurrentJob {
+			result.EmploymentStatus = reviews.EmploymentStatusCurrent
+		}
+	}
+
+	subRatings := map[reviews.Category]float64{
+		reviews.CategoryWorkLifeBalance:      entity.RatingWorkLifeBalance,
+		reviews.CategoryCultureAndValues:     entity.RatingCultureAndValues,
+		reviews.CategoryDiversityInclusion:   entity.RatingDiversityAndInclusion,
+		reviews.CategoryCareerOpportunities:  entity.RatingCareerOpportunities,
+		reviews.CategoryCompensationBenefits: entity.RatingCompensationAndBenefits,
+		reviews.CategorySeniorManagement:     entity.RatingSeniorLeadership,
+	}
+	for category, rating := range subRatings {
+		if rating == 0 {
+			delete(subRatings, category)
+		}
+	}
+	if len(subRatings) > 0 {
+		result.SubRatings = subRatings
+	}
+
+	return result, nil
+}
+
+// ReviewsFunc calls fn for each review entity in the cache in document order,
+// malformed entities are handled according to opts
+func ReviewsFunc(c *Cache, opts reviews.Options, fn func(reviews.Review) error) error {
+	for _, key := range c.Keys() {
+		if _, found := reviewTypes[c.Typename(key)]; !found {
+			continue
+		}
+
+		var entity reviewEntity
+		_, err := c.Get(key, &entity)
+		if err != nil {
+			err = newError("reviews", key, "", err)
+		}
+
+		var review reviews.Review
+		if err == nil {
+			review, err = toReview(c, key, entity)
+		}
+
+		if err != nil {
+			if !opts.Lenient {
+				return err
+			}
+
+			if opts.Diagnostic != nil {
+				opts.Diagnostic(err)
+			}
+			continue
+		}
+
+		if err := fn(review); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasReviews reports whether the cache holds any review entity
+func HasReviews(c *Cache) bool {
+	for _, key := range c.Keys() {
+		if _, found := reviewTypes[c.Typename(key)]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseReviewsFunc extracts the reviews from the embedded application state,
+// falling back to the DOM parser when the document has no valid state or no reviews in it.
+// opts.Selectors only apply to the DOM parser, the state takes precedence when present
+func ParseReviewsFunc(r io.Reader, opts reviews.Options, fn func(reviews.Review) error) error {
+	root, err := html.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	cache, err := Find(root)
+	switch {
+	case err == nil && HasReviews(cache):
+		return ReviewsFunc(cache, opts, fn)
+	case err == nil, fallback(err):
+		return reviews.ParseNode(root, opts, fn)
+	default:
+		return err
+	}
+}
+
+// ParseReviews is like ParseReviewsFunc collecting every review
+func ParseReviews(r io.Reader) ([]reviews.Review, error) {
+	result := make([]reviews.Review, 0)
+
+	err := ParseReviewsFunc(r, reviews.Options{}, func(review reviews.Review) error {
+		result = append(result, review)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
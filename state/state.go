@@ -0,0 +1,238 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jacoelho/openblind"
+	"golang.org/x/net/html"
+)
+
+// rootQuery is the key every normalized apollo cache has
+const rootQuery = "ROOT_QUERY"
+
+var (
+	matcherScript   = openblind.WithTag("script")
+	matcherNextData = openblind.And(matcherScript, openblind.WithID("__NEXT_DATA__"))
+
+	// stateMarkers are the globals the state is assigned to in inline scripts
+	stateMarkers = []string{"__APOLLO_STATE__", "apolloState", "appCache"}
+
+	ErrStateNotFound = errors.New("application state not found")
+	ErrInvalidState  = errors.New("invalid application state")
+)
+
+// Cache is the normalized application state, entities are keyed by "Typename:id"
+// and kept in document order
+type Cache struct {
+	keys     []string
+	entities map[string]json.RawMessage
+}
+
+func (c *Cache) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("expected object: %w", ErrInvalidState)
+	}
+
+	c.keys = nil
+	c.entities = make(map[string]json.RawMessage)
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		key := tok.(string)
+		if _, found := c.entities[key]; !found {
+			c.keys = append(c.keys, key)
+		}
+		c.entities[key] = raw
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+// Keys returns the entity keys in document order
+func (c *Cache) Keys() []string {
+	return c.keys
+}
+
+// Get decodes the entity stored at key into v, reports whether it exists
+func (c *Cache) Get(key string, v interface{}) (bool, error) {
+	raw, found := c.entities[key]
+	if !found {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("%s: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// Typename returns the __typename of the entity stored at key
+func (c *Cache) Typename(key string) string {
+	var entity struct {
+		Typename string `json:"__typename"`
+	}
+
+	if _, err := c.Get(key, &entity); err != nil {
+		return ""
+	}
+
+	return entity.Typename
+}
+
+// entityRef is either a reference to a cache entity or the entity inlined,
+// only the label fields are decoded
+type entityRef struct {
+	Ref  string `json:"__ref"`
+	Text string `json:"text"`
+	Name string `json:"name"`
+}
+
+// label returns the text or name of the entity, following the reference when needed
+func (c *Cache) label(r *entityRef) string {
+	if r == nil {
+		return ""
+	}
+
+	entity := *r
+	if r.Ref != "" {
+		if found, err := c.Get(r.Ref, &entity); !found || err != nil {
+			return ""
+		}
+	}
+
+	if entity.Text != "" {
+		return strings.TrimSpace(entity.Text)
+	}
+
+	return strings.TrimSpace(entity.Name)
+}
+
+// findCache returns the first object, depth first, holding the root query
+func findCache(raw json.RawMessage) (json.RawMessage, bool) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, false
+	}
+
+	if _, found := object[rootQuery]; found {
+		return raw, true
+	}
+
+	keys := make([]string, 0, len(object))
+	for k := range object {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if cache, found := findCache(object[k]); found {
+			return cache, true
+		}
+	}
+
+	return nil, false
+}
+
+func decodeCache(raw json.RawMessage) (*Cache, error) {
+	data, found := findCache(raw)
+	if !found {
+		return nil, ErrStateNotFound
+	}
+
+	var cache Cache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("%s: %w", err.Error(), ErrInvalidState)
+	}
+
+	return &cache, nil
+}
+
+// scriptState decodes the value assigned to the first state marker found in an inline script,
+// trailing statements after the value are ignored
+func scriptState(text string) (json.RawMessage, bool) {
+	for _, marker := range stateMarkers {
+		idx := strings.Index(text, marker)
+		if idx < 0 {
+			continue
+		}
+
+		start := strings.Index(text[idx:], "{")
+		if start < 0 {
+			continue
+		}
+
+		var raw json.RawMessage
+		if err := json.NewDecoder(strings.NewReader(text[idx+start:])).Decode(&raw); err != nil {
+			continue
+		}
+
+		return raw, true
+	}
+
+	return nil, false
+}
+
+func scriptText(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+	}
+	return sb.String()
+}
+
+// fallback reports whether a document should be parsed from the DOM given the error of Find,
+// pages without state or with a malformed one still have the records in their markup
+func fallback(err error) bool {
+	return errors.Is(err, ErrStateNotFound) || errors.Is(err, ErrInvalidState)
+}
+
+// Find locates the application state embedded in the document, either in a __NEXT_DATA__
+// json script or assigned to a global in an inline script.
+// Returns ErrStateNotFound when the document has no state
+func Find(root *html.Node) (*Cache, error) {
+	if node, found := openblind.Find(root, matcherNextData); found {
+		cache, err := decodeCache(json.RawMessage(scriptText(node)))
+		if !errors.Is(err, ErrStateNotFound) {
+			return cache, err
+		}
+	}
+
+	for _, node := range openblind.FindAll(root, matcherScript) {
+		raw, found := scriptState(scriptText(node))
+		if !found {
+			continue
+		}
+
+		cache, err := decodeCache(raw)
+		if !errors.Is(err, ErrStateNotFound) {
+			return cache, err
+		}
+	}
+
+	return nil, ErrStateNotFound
+}
@@ -0,0 +1,306 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+	"golang.org/x/net/html"
+)
+
+const nextDataFixture = `<html><head>
+<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{"apolloCache":{
+	"ROOT_QUERY": {"__typename": "Query"},
+	"JobTitleGD:1": {"__typename": "JobTitleGD", "text": "Global Supply Analyst"},
+	"City:2": {"__typename": "City", "name": "San Francisco, CA"},
+	"EmployerReviewRG:45005756": {
+		"__typename": "EmployerReviewRG",
+		"reviewId": 45005756,
+		"reviewDateTime": "2021-04-04T16:00:47.977",
+		"summary": "Great Company",
+		"ratingOverall": 5,
+		"pros": "Amazing work, very involved in day-to-day details of the company.",
+		"cons": "Work-life balance is not the best.",
+		"advice": null,
+		"ratingWorkLifeBalance": 2,
+		"ratingCultureAndValues": 5,
+		"ratingDiversityAndInclusion": 5,
+		"ratingCareerOpportunities": 5,
+		"ratingCompensationAndBenefits": 3,
+		"ratingSeniorLeadership": 4,
+		"isCurrentJob": true,
+		"jobTitle": {"__ref": "JobTitleGD:1"},
+		"location": {"__ref": "City:2"},
+		"ratingRecommendToFriend": "POSITIVE",
+		"ratingBusinessOutlook": "POSITIVE",
+		"ratingCeo": "APPROVE"
+	},
+	"EmployerReviewRG:45005757": {
+		"__typename": "EmployerReviewRG",
+		"reviewId": 45005757,
+		"reviewDateTime": "2021-04-03T09:30:00",
+		"summary": "Fast paced",
+		"ratingOverall": 3,
+		"pros": "Learning\nPeople",
+		"cons": "Hours",
+		"advice": "Hire more",
+		"isCurrentJob": false,
+		"jobTitle": {"text": "Engineer"},
+		"ratingBusinessOutlook": "NEUTRAL",
+		"ratingCeo": "DISAPPROVE"
+	}
+}}}}</script>
+</head><body><div id="ReviewsFeed"></div></body></html>`
+
+const apolloFixture = `<html><head>
+<script>window.__APOLLO_STATE__ = {
+	"ROOT_QUERY": {"__typename": "Query"},
+	"JobTitleGD:3": {"__typename": "JobTitleGD", "text": "Mechanical Engineer Intern"},
+	"InterviewQuestion:4358096": {
+		"__typename": "InterviewQuestion",
+		"questionId": 4358096,
+		"question": "Why do you want to work for Tesla?",
+		"url": "/Interview/Why-do-you-want-to-work-for-Tesla-QTN_4358096.htm",
+		"answerCount": 2
+	},
+	"EmployerInterviewRG:1": {
+		"__typename": "EmployerInterviewRG",
+		"interviewId": 1,
+		"jobTitle": {"__ref": "JobTitleGD:3"},
+		"processDescription": "featured"
+	},
+	"EmployerInterviewRG:44944117": {
+		"__typename": "EmployerInterviewRG",
+		"interviewId": 44944117,
+		"reviewDateTime": "2021-04-02T10:00:00",
+		"jobTitle": {"__ref": "JobTitleGD:3"},
+		"applicationDetails": "I interviewed at Tesla",
+		"processDescription": "Highly flexible depending on team.",
+		"userQuestions": [{"__ref": "InterviewQuestion:4358096"}],
+		"outcome": "ACCEPTED_OFFER",
+		"experience": "POSITIVE",
+		"difficulty": "AVERAGE"
+	}
+};
+window.other = {};</script>
+</head><body></body></html>`
+
+func TestParseReviews(t *testing.T) {
+	got, err := ParseReviews(strings.NewReader(nextDataFixture))
+	if err != nil {
+		t.Fatalf("ParseReviews() error = %v", err)
+	}
+
+	want := []reviews.Review{
+		{
+			ID:     "45005756",
+			Date:   time.Date(2021, 4, 4, 16, 0, 47, 977000000, time.UTC),
+			Title:  `"Great Company"`,
+			Rating: 5.0,
+			Pros:   []string{"Amazing work, very involved in day-to-day details of the company."},
+			Cons:   []string{"Work-life balance is not the best."},
+			SubRatings: map[reviews.Category]float64{
+				reviews.CategoryWorkLifeBalance:      2.0,
+				reviews.CategoryCultureAndValues:     5.0,
+				reviews.CategoryDiversityInclusion:   5.0,
+				reviews.CategoryCareerOpportunities:  5.0,
+				reviews.CategoryCompensationBenefits: 3.0,
+				reviews.CategorySeniorManagement:     4.0,
+			},
+			EmploymentStatus: reviews.EmploymentStatusCurrent,
+			JobTitle:         "Global Supply Analyst",
+			Location:         "San Francisco, CA",
+			Recommends:       reviews.IndicatorPositive,
+			Outlook:          reviews.IndicatorPositive,
+			CEOApproval:      reviews.IndicatorPositive,
+		},
+		{
+			ID:               "45005757",
+			Date:             time.Date(2021, 4, 3, 9, 30, 0, 0, time.UTC),
+			Title:            `"Fast paced"`,
+			Rating:           3.0,
+			Pros:             []string{"Learning", "People"},
+			Cons:             []string{"Hours"},
+			Advice:           []string{"Hire more"},
+			EmploymentStatus: reviews.EmploymentStatusFormer,
+			JobTitle:         "Engineer",
+			Outlook:          reviews.IndicatorNeutral,
+			CEOApproval:      reviews.IndicatorNegative,
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseReviews() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseReviewsLenient(t *testing.T) {
+	page := strings.Replace(nextDataFixture, `"cons": "Hours",`, ``, 1)
+
+	if _, err := ParseReviews(strings.NewReader(page)); !errors.Is(err, reviews.ErrParseCons) {
+		t.Fatalf("ParseReviews() error = %v, want %v", err, reviews.ErrParseCons)
+	}
+
+	var (
+		ids         []string
+		diagnostics []error
+	)
+
+	opts := reviews.Options{
+		Lenient: true,
+		Diagnostic: func(err error) {
+			diagnostics = append(diagnostics, err)
+		},
+	}
+
+	err := ParseReviewsFunc(strings.NewReader(page), opts, func(review reviews.Review) error {
+		ids = append(ids, review.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseReviewsFunc() error = %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"45005756"}, ids); diff != "" {
+		t.Errorf("ParseReviewsFunc() ids mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(diagnostics) != 1 || !errors.Is(diagnostics[0], reviews.ErrParseCons) {
+		t.Errorf("ParseReviewsFunc() diagnostics = %v, want %v", diagnostics, reviews.ErrParseCons)
+	}
+}
+
+func TestParseInterviews(t *testing.T) {
+	got, err := ParseInterviews(strings.NewReader(apolloFixture))
+	if err != nil {
+		t.Fatalf("ParseInterviews() error = %v", err)
+	}
+
+	want := []interviews.Interview{
+		{
+			ID:          "44944117",
+			Date:        time.Date(2021, 4, 2, 10, 0, 0, 0, time.UTC),
+			Title:       "Mechanical Engineer Intern Interview",
+			Application: []string{"I interviewed at Tesla"},
+			Process:     []string{"Highly flexible depending on team."},
+			Questions: []interviews.Question{
+				{
+					ID:      "4358096",
+					Text:    "Why do you want to work for Tesla?",
+					URL:     "https://www.glassdoor.com/Interview/Why-do-you-want-to-work-for-Tesla-QTN_4358096.htm",
+					Answers: 2,
+				},
+			},
+			Offer:      interviews.OfferAccepted,
+			Experience: interviews.ExperiencePositive,
+			Difficulty: interviews.DifficultyAverage,
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseInterviews() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseFallback(t *testing.T) {
+	page := `<html><body><div id="ReviewsFeed"><ol><li id="empReview_1">
+		<time class="date" datetime="Sun Apr 04 2021 17:00:47 GMT+0100 (British Summer Time)"></time>
+		<h2 class="h2 summary">Title</h2>
+		<span class="rating"><span title="4.0"></span></span>
+		<span data-test="pros">pros</span>
+		<span data-test="cons">cons</span>
+	</li></ol></div></body></html>`
+
+	got, err := ParseReviews(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("ParseReviews() error = %v", err)
+	}
+
+	if len(got) != 1 || got[0].ID != "1" || got[0].Title != "Title" {
+		t.Errorf("ParseReviews() = %+v", got)
+	}
+
+	// state without interviews falls back to the DOM parser
+	_, err = ParseInterviews(strings.NewReader(nextDataFixture))
+	if err == nil {
+		t.Error("ParseInterviews() expected list not found error")
+	}
+}
+
+func TestFallback(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "state found", err: nil, want: false},
+		{name: "no state", err: ErrStateNotFound, want: true},
+		{name: "malformed state", err: fmt.Errorf("unexpected end of JSON input: %w", ErrInvalidState), want: true},
+		{name: "other error", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fallback(tt.err); got != tt.want {
+				t.Errorf("fallback() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []string
+		wantErr  error
+	}{
+		{
+			name:     "next data",
+			document: `<script id="__NEXT_DATA__" type="application/json">{"props":{"apolloState":{"ROOT_QUERY":{},"A:1":{},"B:2":{}}}}</script>`,
+			want:     []string{"ROOT_QUERY", "A:1", "B:2"},
+		},
+		{
+			name:     "inline script keeps document order",
+			document: `<script>var x = 1;</script><script>window.appCache = {"apolloState": {"ROOT_QUERY":{},"B:2":{},"A:1":{}}};</script>`,
+			want:     []string{"ROOT_QUERY", "B:2", "A:1"},
+		},
+		{
+			name:     "no state",
+			document: `<script>window.appCache = {"other": {}};</script>`,
+			wantErr:  ErrStateNotFound,
+		},
+		{
+			name:     "malformed state",
+			document: `<script>window.__APOLLO_STATE__ = {"ROOT_QUERY": </script>`,
+			wantErr:  ErrStateNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(tt.document))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cache, err := Find(root)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Find() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, cache.Keys()); diff != "" {
+				t.Errorf("Find() keys mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
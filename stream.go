@@ -0,0 +1,155 @@
+package openblind
+
+import (
+	"errors"
+	"io"
+
+	"golang.org/x/net/html"
+)
+
+var ErrListNotFound = errors.New("list not found")
+
+// voidElements never have children nor an end tag
+var voidElements = map[string]struct{}{
+	"area":   {},
+	"base":   {},
+	"br":     {},
+	"col":    {},
+	"embed":  {},
+	"hr":     {},
+	"img":    {},
+	"input":  {},
+	"link":   {},
+	"meta":   {},
+	"param":  {},
+	"source": {},
+	"track":  {},
+	"wbr":    {},
+}
+
+// StreamNodes tokenizes r in a single pass calling fn with the subtree of every node
+// matching record inside a node matching list.
+// Only the current record subtree is built, its ancestors are kept without children so
+// matchers looking at attributes or ancestors behave as with html.Parse.
+// Unlike html.Parse no implied tags are inserted, end tags close the nearest open element
+// with the same name. Returns ErrListNotFound when no node matches list.
+func StreamNodes(r io.Reader, list, record Matcher, fn func(*html.Node) error) error {
+	z := html.NewTokenizer(r)
+
+	var (
+		stack     = []*html.Node{{Type: html.DocumentNode}}
+		listDepth = -1
+		listFound = false
+		current   *html.Node
+		depth     = -1
+	)
+
+	// push adds n as a child of the top of the stack, only nodes inside a record are linked
+	push := func(n *html.Node, open bool) {
+		parent := stack[len(stack)-1]
+
+		if current != nil {
+			parent.AppendChild(n)
+		} else {
+			n.Parent = parent
+		}
+
+		if open {
+			stack = append(stack, n)
+		}
+	}
+
+	for {
+		tt := z.Next()
+
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return err
+			}
+
+			// unterminated record at the end of the document
+			if current != nil {
+				if err := fn(current); err != nil {
+					return err
+				}
+			}
+
+			if !listFound {
+				return ErrListNotFound
+			}
+			return nil
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			n := &html.Node{
+				Type:     html.ElementNode,
+				Data:     tok.Data,
+				DataAtom: tok.DataAtom,
+				Attr:     tok.Attr,
+			}
+
+			_, void := voidElements[tok.Data]
+			open := tt == html.StartTagToken && !void
+
+			push(n, open)
+
+			switch {
+			case current != nil:
+			case listDepth >= 0 && record.Match(n):
+				current = n
+				depth = len(stack) - 1
+
+				if !open {
+					current = nil
+					if err := fn(n); err != nil {
+						return err
+					}
+				}
+			case listDepth < 0 && open && list.Match(n):
+				listDepth = len(stack) - 1
+				listFound = true
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+
+			idx := -1
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].Data == string(name) {
+					idx = i
+					break
+				}
+			}
+
+			if idx < 0 {
+				continue
+			}
+
+			stack = stack[:idx]
+
+			if current != nil && idx <= depth {
+				n := current
+				current, depth = nil, -1
+
+				if err := fn(n); err != nil {
+					return err
+				}
+			}
+
+			if listDepth >= 0 && idx <= listDepth {
+				listDepth = -1
+			}
+
+		case html.TextToken:
+			if current != nil {
+				push(&html.Node{Type: html.TextNode, Data: string(z.Text())}, false)
+			}
+
+		case html.CommentToken:
+			if current != nil {
+				push(&html.Node{Type: html.CommentNode, Data: string(z.Text())}, false)
+			}
+		}
+	}
+}
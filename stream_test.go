@@ -0,0 +1,115 @@
+package openblind
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestStreamNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    [][]string
+		wantErr error
+	}{
+		{
+			name: "records inside list",
+			input: `<div class="other"><p class="record">outside</p></div>
+<ul id="list">
+	<li class="record">first <img src="a.png"> <b>bold</b><br>end</li>
+	<li class="record">second<!-- comment --></li>
+</ul>
+<p class="record">after list</p>`,
+			want: [][]string{
+				{"first ", " ", "bold", "end"},
+				{"second"},
+			},
+		},
+		{
+			name: "mismatched end tags",
+			input: `<ul id="list">
+	<li class="record"><span>unclosed <b>tags</li>
+	<li class="record">stray</i> end</li>
+</ul>`,
+			want: [][]string{
+				{"unclosed ", "tags"},
+				{"stray", " end"},
+			},
+		},
+		{
+			name:  "unterminated record",
+			input: `<ul id="list"><li class="record">truncated`,
+			want: [][]string{
+				{"truncated"},
+			},
+		},
+		{
+			name:  "entities",
+			input: `<ul id="list"><li class="record">Culture &amp; Values</li></ul>`,
+			want: [][]string{
+				{"Culture & Values"},
+			},
+		},
+		{
+			name:    "list not found",
+			input:   `<ul><li class="record">first</li></ul>`,
+			wantErr: ErrListNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+
+			err := StreamNodes(strings.NewReader(tt.input), WithID("list"), HasClass("record"), func(n *html.Node) error {
+				got = append(got, ExtractText(n))
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("StreamNodes() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("StreamNodes() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStreamNodesAncestors(t *testing.T) {
+	input := `<div id="feed" class="wrapper"><ol id="list"><li class="record"><span>text</span></li></ol></div>`
+
+	var matched bool
+	err := StreamNodes(strings.NewReader(input), WithID("list"), HasClass("record"), func(n *html.Node) error {
+		matched = HasAncestor(WithID("feed")).Match(n)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamNodes() error = %v", err)
+	}
+
+	if !matched {
+		t.Error("StreamNodes() record ancestors not available")
+	}
+}
+
+func TestStreamNodesStopsOnError(t *testing.T) {
+	errStop := errors.New("stop")
+
+	calls := 0
+	err := StreamNodes(strings.NewReader(`<ul id="list"><li class="record">1</li><li class="record">2</li></ul>`), WithID("list"), HasClass("record"), func(*html.Node) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("StreamNodes() error = %v, want %v", err, errStop)
+	}
+
+	if calls != 1 {
+		t.Errorf("StreamNodes() calls = %d, want 1", calls)
+	}
+}
@@ -0,0 +1,255 @@
+package writer
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+	"github.com/jacoelho/openblind/section"
+)
+
+const DefaultSeparator = "; "
+
+var ErrMixedRecords = errors.New("reviews and interviews written to the same output")
+
+type Options struct {
+	// Separator joins list fields such as pros or questions, defaults to DefaultSeparator
+	Separator string
+
+	// Section and Company select the header row written on Flush when no record was written,
+	// Company adds the columns of records tagged with their company
+	Section section.Section
+	Company bool
+}
+
+type recordKind int
+
+const (
+	kindNone recordKind = iota
+	kindReview
+	kindInterview
+	kindCompanyReview
+	kindCompanyInterview
+)
+
+// companyColumns prefix the header row of tagged records
+var companyColumns = []string{"company", "companyId"}
+
+// CSV writes records as comma or tab separated values, a header row
+// is written before the first record, or on Flush for an empty output of a known section
+type CSV struct {
+	w         *csv.Writer
+	separator string
+	kind      recordKind
+
+	section section.Section
+	company bool
+}
+
+// NewCSV returns a writer of comma separated values
+func NewCSV(w io.Writer, opts Options) *CSV {
+	return newCSV(w, ',', opts)
+}
+
+// NewTSV returns a writer of tab separated values
+func NewTSV(w io.Writer, opts Options) *CSV {
+	return newCSV(w, '\t', opts)
+}
+
+func newCSV(w io.Writer, comma rune, opts Options) *CSV {
+	separator := opts.Separator
+	if separator == "" {
+		separator = DefaultSeparator
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	return &CSV{
+		w:         cw,
+		separator: separator,
+		section:   opts.Section,
+		company:   opts.Company,
+	}
+}
+
+// ReviewColumns returns the header row used for reviews
+func ReviewColumns() []string {
+	columns := []string{
+		"id",
+		"date",
+		"title",
+		"rating",
+	}
+
+	for _, category := range reviews.Categories {
+		columns = append(columns, string(category))
+	}
+
+	return append(columns,
+		"employmentStatus",
+		"jobTitle",
+		"location",
+		"recommends",
+		"outlook",
+		"ceoApproval",
+		"pros",
+		"cons",
+		"advice",
+	)
+}
+
+// InterviewColumns returns the header row used for interviews
+func InterviewColumns() []string {
+	return []string{
+		"id",
+		"date",
+		"title",
+		"offer",
+		"experience",
+		"difficulty",
+		"application",
+		"process",
+		"questions",
+	}
+}
+
+func (c *CSV) header(kind recordKind, columns []string) error {
+	if c.kind == kind {
+		return nil
+	}
+
+	if c.kind != kindNone {
+		return ErrMixedRecords
+	}
+
+	c.kind = kind
+	return c.w.Write(columns)
+}
+
+func (c *CSV) WriteReview(r reviews.Review) error {
+	if err := c.header(kindReview, ReviewColumns()); err != nil {
+		return err
+	}
+
+	return c.w.Write(c.reviewRow(r))
+}
+
+func (c *CSV) WriteCompanyReview(r CompanyReview) error {
+	if err := c.header(kindCompanyReview, append(companyColumns, ReviewColumns()...)); err != nil {
+		return err
+	}
+
+	return c.w.Write(append([]string{r.Company, r.CompanyID}, c.reviewRow(r.Review)...))
+}
+
+func (c *CSV) reviewRow(r reviews.Review) []string {
+	row := []string{
+		r.ID,
+		formatTime(r.Date),
+		r.Title,
+		formatFloat(r.Rating),
+	}
+
+	for _, category := range reviews.Categories {
+		row = append(row, formatFloat(r.SubRatings[category]))
+	}
+
+	row = append(row,
+		string(r.EmploymentStatus),
+		r.JobTitle,
+		r.Location,
+		string(r.Recommends),
+		string(r.Outlook),
+		string(r.CEOApproval),
+		strings.Join(r.Pros, c.separator),
+		strings.Join(r.Cons, c.separator),
+		strings.Join(r.Advice, c.separator),
+	)
+
+	return row
+}
+
+func (c *CSV) WriteInterview(i interviews.Interview) error {
+	if err := c.header(kindInterview, InterviewColumns()); err != nil {
+		return err
+	}
+
+	return c.w.Write(c.interviewRow(i))
+}
+
+func (c *CSV) WriteCompanyInterview(i CompanyInterview) error {
+	if err := c.header(kindCompanyInterview, append(companyColumns, InterviewColumns()...)); err != nil {
+		return err
+	}
+
+	return c.w.Write(append([]string{i.Company, i.CompanyID}, c.interviewRow(i.Interview)...))
+}
+
+func (c *CSV) interviewRow(i interviews.Interview) []string {
+	questions := make([]string, len(i.Questions))
+	for idx, q := range i.Questions {
+		questions[idx] = q.Text
+	}
+
+	return []string{
+		i.ID,
+		formatTime(i.Date),
+		i.Title,
+		string(i.Offer),
+		string(i.Experience),
+		string(i.Difficulty),
+		strings.Join(i.Application, c.separator),
+		strings.Join(i.Process, c.separator),
+		strings.Join(questions, c.separator),
+	}
+}
+
+// emptyHeader writes the header row of the configured section when nothing was written
+func (c *CSV) emptyHeader() error {
+	if c.kind != kindNone {
+		return nil
+	}
+
+	switch {
+	case c.section == section.Reviews && c.company:
+		return c.header(kindCompanyReview, append(companyColumns, ReviewColumns()...))
+	case c.section == section.Reviews:
+		return c.header(kindReview, ReviewColumns())
+	case c.section == section.Interviews && c.company:
+		return c.header(kindCompanyInterview, append(companyColumns, InterviewColumns()...))
+	case c.section == section.Interviews:
+		return c.header(kindInterview, InterviewColumns())
+	default:
+		return nil
+	}
+}
+
+// Flush writes any buffered data to the underlying writer
+func (c *CSV) Flush() error {
+	if err := c.emptyHeader(); err != nil {
+		return err
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatFloat(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
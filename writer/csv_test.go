@@ -0,0 +1,185 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+	"github.com/jacoelho/openblind/section"
+)
+
+func TestCSVReviews(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewCSV(&buf, Options{})
+
+	records := []reviews.Review{
+		{
+			ID:     "1",
+			Date:   time.Date(2021, 4, 4, 16, 0, 47, 0, time.UTC),
+			Title:  `"Great Company"`,
+			Rating: 4.5,
+			Pros:   []string{"first, line", "second\nline"},
+			Cons:   []string{"cons"},
+			SubRatings: map[reviews.Category]float64{
+				reviews.CategoryWorkLifeBalance: 2,
+			},
+			EmploymentStatus: reviews.EmploymentStatusCurrent,
+			JobTitle:         "Analyst",
+			Location:         "San Francisco, CA",
+			Recommends:       reviews.IndicatorPositive,
+		},
+		{
+			ID: "2",
+		},
+	}
+
+	for _, r := range records {
+		if err := w.WriteReview(r); err != nil {
+			t.Fatalf("WriteReview() error = %v", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	want := `id,date,title,rating,workLifeBalance,cultureAndValues,diversityAndInclusion,careerOpportunities,compensationAndBenefits,seniorManagement,employmentStatus,jobTitle,location,recommends,outlook,ceoApproval,pros,cons,advice
+1,2021-04-04T16:00:47Z,"""Great Company""",4.5,2,,,,,,current,Analyst,"San Francisco, CA",positive,,,"first, line; second
+line",cons,
+2,,,,,,,,,,,,,,,,,,
+`
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("CSV mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTSVInterviews(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewTSV(&buf, Options{Separator: "|"})
+
+	err := w.WriteInterview(interviews.Interview{
+		ID:          "44944117",
+		Date:        time.Date(2021, 4, 2, 0, 0, 0, 0, time.UTC),
+		Title:       "Mechanical Engineer Intern Interview",
+		Application: []string{"I interviewed at Tesla"},
+		Process:     []string{"Phone screen", "Onsite"},
+		Questions: []interviews.Question{
+			{ID: "1", Text: "Why Tesla?"},
+			{ID: "2", Text: "Describe a project"},
+		},
+		Offer:      interviews.OfferAccepted,
+		Experience: interviews.ExperiencePositive,
+		Difficulty: interviews.DifficultyAverage,
+	})
+	if err != nil {
+		t.Fatalf("WriteInterview() error = %v", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	want := "id\tdate\ttitle\toffer\texperience\tdifficulty\tapplication\tprocess\tquestions\n" +
+		"44944117\t2021-04-02T00:00:00Z\tMechanical Engineer Intern Interview\taccepted\tpositive\taverage\tI interviewed at Tesla\tPhone screen|Onsite\tWhy Tesla?|Describe a project\n"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("TSV mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCSVEmpty(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "unknown section",
+			opts: Options{},
+			want: "",
+		},
+		{
+			name: "interviews",
+			opts: Options{Section: section.Interviews},
+			want: "id,date,title,offer,experience,difficulty,application,process,questions\n",
+		},
+		{
+			name: "company interviews",
+			opts: Options{Section: section.Interviews, Company: true},
+			want: "company,companyId,id,date,title,offer,experience,difficulty,application,process,questions\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w := NewCSV(&buf, tt.opts)
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			// a second flush does not repeat the header
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("CSV mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCSVMixedRecords(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewCSV(&buf, Options{})
+
+	if err := w.WriteReview(reviews.Review{ID: "1"}); err != nil {
+		t.Fatalf("WriteReview() error = %v", err)
+	}
+
+	if err := w.WriteInterview(interviews.Interview{ID: "2"}); !errors.Is(err, ErrMixedRecords) {
+		t.Errorf("WriteInterview() error = %v, want %v", err, ErrMixedRecords)
+	}
+}
+
+func TestCSVCompanyReviews(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewCSV(&buf, Options{})
+
+	for _, r := range []CompanyReview{
+		{Company: "Tesla", CompanyID: "43129", Review: reviews.Review{ID: "1", Rating: 4}},
+		{Company: "Goldman-Sachs", CompanyID: "2800", Review: reviews.Review{ID: "2"}},
+	} {
+		if err := w.WriteCompanyReview(r); err != nil {
+			t.Fatalf("WriteCompanyReview() error = %v", err)
+		}
+	}
+
+	if err := w.WriteReview(reviews.Review{ID: "3"}); !errors.Is(err, ErrMixedRecords) {
+		t.Errorf("WriteReview() error = %v, want %v", err, ErrMixedRecords)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	want := `company,companyId,id,date,title,rating,workLifeBalance,cultureAndValues,diversityAndInclusion,careerOpportunities,compensationAndBenefits,seniorManagement,employmentStatus,jobTitle,location,recommends,outlook,ceoApproval,pros,cons,advice
+Tesla,43129,1,,,4,,,,,,,,,,,,,,,
+Goldman-Sachs,2800,2,,,,,,,,,,,,,,,,,,
+`
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("CSV mismatch (-want +got):\n%s", diff)
+	}
+}
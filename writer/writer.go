@@ -0,0 +1,125 @@
+package writer
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+)
+
+// Writer receives records as they are parsed, Flush must be called once done
+type Writer interface {
+	WriteReview(reviews.Review) error
+	WriteInterview(interviews.Interview) error
+	Flush() error
+}
+
+// CompanyReview is a review tagged with the company it belongs to
+type CompanyReview struct {
+	Company   string `json:"company"`
+	CompanyID string `json:"companyId"`
+	reviews.Review
+}
+
+// CompanyInterview is an interview tagged with the company it belongs to
+type CompanyInterview struct {
+	Company   string `json:"company"`
+	CompanyID string `json:"companyId"`
+	interviews.Interview
+}
+
+// CompanyWriter also receives records of several companies, tagged records
+// cannot be mixed with untagged ones in csv output
+type CompanyWriter interface {
+	Writer
+	WriteCompanyReview(CompanyReview) error
+	WriteCompanyInterview(CompanyInterview) error
+}
+
+// JSON writes records as an indented JSON array, records are written as they arrive
+type JSON struct {
+	w     io.Writer
+	count int
+}
+
+func NewJSON(w io.Writer) *JSON {
+	return &JSON{w: w}
+}
+
+func (j *JSON) write(v interface{}) error {
+	data, err := json.MarshalIndent(v, "\t", "\t")
+	if err != nil {
+		return err
+	}
+
+	prefix := ",\n\t"
+	if j.count == 0 {
+		prefix = "[\n\t"
+	}
+	j.count++
+
+	if _, err := io.WriteString(j.w, prefix); err != nil {
+		return err
+	}
+
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *JSON) WriteReview(r reviews.Review) error {
+	return j.write(r)
+}
+
+func (j *JSON) WriteInterview(i interviews.Interview) error {
+	return j.write(i)
+}
+
+func (j *JSON) WriteCompanyReview(r CompanyReview) error {
+	return j.write(r)
+}
+
+func (j *JSON) WriteCompanyInterview(i CompanyInterview) error {
+	return j.write(i)
+}
+
+// Flush closes the array
+func (j *JSON) Flush() error {
+	suffix := "\n]\n"
+	if j.count == 0 {
+		suffix = "[]\n"
+	}
+
+	_, err := io.WriteString(j.w, suffix)
+	return err
+}
+
+// NDJSON writes each record as a JSON object in its own line
+type NDJSON struct {
+	enc *json.Encoder
+}
+
+func NewNDJSON(w io.Writer) *NDJSON {
+	return &NDJSON{enc: json.NewEncoder(w)}
+}
+
+func (n *NDJSON) WriteReview(r reviews.Review) error {
+	return n.enc.Encode(r)
+}
+
+func (n *NDJSON) WriteInterview(i interviews.Interview) error {
+	return n.enc.Encode(i)
+}
+
+func (n *NDJSON) WriteCompanyReview(r CompanyReview) error {
+	return n.enc.Encode(r)
+}
+
+func (n *NDJSON) WriteCompanyInterview(i CompanyInterview) error {
+	return n.enc.Encode(i)
+}
+
+// Flush is a no-op, records are written as they arrive
+func (n *NDJSON) Flush() error {
+	return nil
+}
@@ -0,0 +1,104 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jacoelho/openblind/interviews"
+	"github.com/jacoelho/openblind/reviews"
+)
+
+func TestJSON(t *testing.T) {
+	records := []reviews.Review{
+		{ID: "1", Title: "first", Pros: []string{"pros"}},
+		{ID: "2", Title: "second"},
+	}
+
+	tests := []struct {
+		name    string
+		records []reviews.Review
+	}{
+		{name: "empty", records: []reviews.Review{}},
+		{name: "single", records: records[:1]},
+		{name: "multiple", records: records},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w := NewJSON(&buf)
+			for _, r := range tt.records {
+				if err := w.WriteReview(r); err != nil {
+					t.Fatalf("WriteReview() error = %v", err)
+				}
+			}
+
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+
+			// output must match encoding the whole slice at once
+			var want bytes.Buffer
+			enc := json.NewEncoder(&want)
+			enc.SetIndent("", "\t")
+			if err := enc.Encode(tt.records); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(want.String(), buf.String()); diff != "" {
+				t.Errorf("JSON mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewNDJSON(&buf)
+
+	if err := w.WriteInterview(interviews.Interview{ID: "1", Offer: interviews.OfferAccepted}); err != nil {
+		t.Fatalf("WriteInterview() error = %v", err)
+	}
+
+	if err := w.WriteInterview(interviews.Interview{ID: "2"}); err != nil {
+		t.Fatalf("WriteInterview() error = %v", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	want := `{"id":"1","date":"0001-01-01T00:00:00Z","offer":"accepted"}
+{"id":"2","date":"0001-01-01T00:00:00Z"}
+`
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("NDJSON mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNDJSONCompany(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewNDJSON(&buf)
+
+	err := w.WriteCompanyInterview(CompanyInterview{
+		Company:   "Tesla",
+		CompanyID: "43129",
+		Interview: interviews.Interview{ID: "1", Offer: interviews.OfferAccepted},
+	})
+	if err != nil {
+		t.Fatalf("WriteCompanyInterview() error = %v", err)
+	}
+
+	want := `{"company":"Tesla","companyId":"43129","id":"1","date":"0001-01-01T00:00:00Z","offer":"accepted"}
+`
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("NDJSON mismatch (-want +got):\n%s", diff)
+	}
+}